@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wvoliveira/corgi/internal/pkg/config"
+	"github.com/wvoliveira/corgi/internal/pkg/database"
+	"github.com/wvoliveira/corgi/internal/pkg/location"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+)
+
+func init() {
+	config.New()
+	logger.Default()
+}
+
+// Import a CSV IP range database to enrich clicks with country, state and city.
+// Ex.: corgi-location -format dbip dbip-city-lite-2023-01.csv
+func main() {
+	ctx := context.Background()
+	log := logger.Logger(ctx)
+
+	format := flag.String("format", location.FormatDBIP,
+		fmt.Sprintf("layout of CSV file: '%s' or '%s'", location.FormatDBIP, location.FormatIP2Location))
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [-format %s|%s] <file.csv>\n",
+			os.Args[0], location.FormatDBIP, location.FormatIP2Location)
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal().Caller().Msg(err.Error())
+	}
+
+	defer file.Close()
+
+	db := database.NewSQL()
+
+	total, err := location.Load(ctx, db, file, *format)
+	if err != nil {
+		log.Fatal().Caller().Msg(fmt.Sprintf("error to load locations: %s", err.Error()))
+	}
+
+	log.Info().Caller().Msg(fmt.Sprintf("%d IP ranges loaded from '%s'", total, flag.Arg(0)))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/gob"
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
@@ -13,10 +14,14 @@ import (
 	"github.com/wvoliveira/corgi/internal/app/auth/password"
 	"github.com/wvoliveira/corgi/internal/app/auth/token"
 	"github.com/wvoliveira/corgi/internal/app/click"
+	"github.com/wvoliveira/corgi/internal/app/domain"
+	"github.com/wvoliveira/corgi/internal/app/folder"
 	"github.com/wvoliveira/corgi/internal/app/group"
 	"github.com/wvoliveira/corgi/internal/app/health"
 	"github.com/wvoliveira/corgi/internal/app/link"
+	"github.com/wvoliveira/corgi/internal/app/tag"
 	"github.com/wvoliveira/corgi/internal/app/user"
+	"github.com/wvoliveira/corgi/internal/app/utm"
 	"github.com/wvoliveira/corgi/internal/pkg/config"
 	"github.com/wvoliveira/corgi/internal/pkg/database"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
@@ -26,6 +31,7 @@ import (
 	"github.com/wvoliveira/corgi/internal/pkg/server"
 	"github.com/wvoliveira/corgi/web"
 	"net/http"
+	"time"
 )
 
 const version = "0.0.1"
@@ -59,11 +65,17 @@ func main() {
 	router.Use(middleware.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORS())
-	router.Use(middleware.Authentication())
+	router.Use(middleware.Authentication(db, cache))
 	router.Use(middleware.Authorization(enforcer))
 
 	apiRouter := router.Group("/api")
 
+	// Web interface, for requests that do not have a router defined.
+	webHandler := func(c *gin.Context) {
+		reqPath := c.Request.URL.Path
+		c.FileFromFS(reqPath, http.FS(web.DistFS))
+	}
+
 	// Enable /metrics path Prometheus metrics like.
 	// And middleware to add some basic metrics from routes.
 	server.NewMetrics(apiRouter)
@@ -77,8 +89,8 @@ func main() {
 	}
 
 	{
-		// Auth service: logout and check.
-		service := token.NewService(db)
+		// Auth token service: validate, refresh and logout.
+		service := token.NewService(db, cache)
 		service.NewHTTP(apiRouter)
 	}
 
@@ -112,10 +124,44 @@ func main() {
 		service.NewHTTP(apiRouter)
 	}
 
+	{
+		// Custom domains from users and groups, with ownership verification.
+		service := domain.NewService(db, cache)
+		service.NewHTTP(apiRouter)
+	}
+
+	{
+		// Tags from users to organize links.
+		service := tag.NewService(db, cache)
+		service.NewHTTP(apiRouter)
+	}
+
+	{
+		// Folders from users to keep links.
+		service := folder.NewService(db, cache)
+		service.NewHTTP(apiRouter)
+	}
+
+	{
+		// Reusable UTM parameters from users and groups, to create links.
+		service := utm.NewService(db, cache)
+		service.NewHTTP(apiRouter)
+	}
+
 	{
 		// Central business service: manage link shortener.
 		service := link.NewService(db, cache)
-		service.NewHTTP(router, apiRouter)
+		service.NewHTTP(router, apiRouter, webHandler)
+
+		// Persist click counters from cache to database.
+		interval := time.Duration(viper.GetInt("CLICKS_SYNC_INTERVAL")) * time.Second
+		go service.NewWorker(context.Background(), interval)
+
+		// Purge deleted links after retention, so their keywords can be used again.
+		if days := viper.GetInt("LINKS_TRASH_RETENTION_DAYS"); days > 0 {
+			retention := time.Duration(days) * 24 * time.Hour
+			go service.NewTrashWorker(context.Background(), retention)
+		}
 	}
 
 	{
@@ -132,10 +178,7 @@ func main() {
 	}
 
 	// Send requests that do not have a router defined.
-	router.NoRoute(func(c *gin.Context) {
-		reqPath := c.Request.URL.Path
-		c.FileFromFS(reqPath, http.FS(web.DistFS))
-	})
+	router.NoRoute(webHandler)
 
 	server.Graceful(router, viper.GetInt("SERVER_HTTP_PORT"))
 }
@@ -18,6 +18,7 @@ require (
 	github.com/prometheus/client_golang v1.14.0
 	github.com/redis/go-redis/v9 v9.0.2
 	github.com/rs/zerolog v1.26.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/viper v1.9.0
 	github.com/swaggo/swag v1.7.4
 	github.com/teris-io/shortid v0.0.0-20201117134242-e59966efd125
@@ -1 +1,63 @@
 package token
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+)
+
+type validRequest struct {
+	AccessToken string
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type logoutRequest struct {
+	AccessToken  string
+	RefreshToken string `json:"refresh_token"`
+}
+
+func decodeValid(c *gin.Context) (req validRequest, err error) {
+	req.AccessToken, err = bearerToken(c)
+	return
+}
+
+func decodeRefresh(c *gin.Context) (req refreshRequest, err error) {
+	err = c.ShouldBindJSON(&req)
+	return
+}
+
+// decodeLogout get access token from header and refresh token from body, if any.
+func decodeLogout(c *gin.Context) (req logoutRequest, err error) {
+	req.AccessToken, err = bearerToken(c)
+	if err != nil {
+		return
+	}
+
+	if c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&req)
+	}
+	return
+}
+
+func decodeLogoutAll(c *gin.Context) (req validRequest, err error) {
+	req.AccessToken, err = bearerToken(c)
+	return
+}
+
+func bearerToken(c *gin.Context) (token string, err error) {
+	headerAuth := c.GetHeader("Authorization")
+	if headerAuth == "" {
+		return token, e.ErrNoTokenFound
+	}
+
+	if !strings.HasPrefix(headerAuth, "Bearer ") {
+		return token, e.ErrAuthHeaderFormat
+	}
+
+	return strings.TrimPrefix(headerAuth, "Bearer "), nil
+}
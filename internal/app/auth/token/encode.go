@@ -1 +1,47 @@
 package token
+
+import (
+	"time"
+
+	tk "github.com/wvoliveira/corgi/internal/pkg/token"
+)
+
+type validResponse struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // Seconds until token expires.
+}
+
+type refreshResponse struct {
+	Tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	} `json:"tokens"`
+}
+
+func encodeValid(claims tk.JWTClaim) (r validResponse) {
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+
+	r.ID = claims.Id
+	r.Type = claims.Type
+	r.UserID = claims.User.ID
+	r.Username = claims.Username
+	r.Name = claims.Name
+	r.Role = claims.Role
+	r.IssuedAt = time.Unix(claims.IssuedAt, 0)
+	r.ExpiresAt = expiresAt
+	r.ExpiresIn = int64(time.Until(expiresAt).Seconds())
+	return
+}
+
+func encodeRefresh(accessToken, refreshToken string) (r refreshResponse) {
+	r.Tokens.AccessToken = accessToken
+	r.Tokens.RefreshToken = refreshToken
+	return
+}
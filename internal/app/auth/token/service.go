@@ -2,45 +2,205 @@ package token
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+	tk "github.com/wvoliveira/corgi/internal/pkg/token"
 )
 
+const keyRefreshUsed = "token_refresh_used:%s" // Ex.: token_refresh_used:<jti>
+
 // Service encapsulates the authentication logic.
 type Service interface {
-	Valid(*gin.Context, string) error
-	Refresh(*gin.Context, string) (string, error)
+	Valid(*gin.Context, string) (tk.JWTClaim, error)
+	Refresh(*gin.Context, string) (string, string, error)
+	Logout(*gin.Context, logoutRequest) error
+	LogoutAll(*gin.Context, string) error
 
 	NewHTTP(*gin.RouterGroup)
 	HTTPValid(c *gin.Context)
 	HTTPRefresh(c *gin.Context)
+	HTTPLogout(c *gin.Context)
+	HTTPLogoutAll(c *gin.Context)
 }
 
 type service struct {
-	db *sql.DB
+	db    *sql.DB
+	cache *redis.Client
 }
 
 // NewService creates a new authentication service.
-func NewService(db *sql.DB) Service {
-	return service{db}
+func NewService(db *sql.DB, cache *redis.Client) Service {
+	return service{db, cache}
 }
 
-// Valid verify access token.
-func (s service) Valid(c *gin.Context, accessToken string) (err error) {
+// Valid verify access token and return its claims.
+func (s service) Valid(c *gin.Context, accessToken string) (claims tk.JWTClaim, err error) {
 	log := logger.Logger(c)
 
-	log.Warn().Caller().Msg(err.Error())
-	err = e.ErrNotImplemented
-	return
+	parsed, err := tk.ParseToken(accessToken)
+	if err != nil {
+		log.Info().Caller().Msg(err.Error())
+		return claims, e.ErrTokenInvalid
+	}
+
+	if parsed.Type == tk.TypeRefresh {
+		log.Info().Caller().Msg("refresh token sent to validation of access token")
+		return claims, e.ErrTokenInvalid
+	}
+
+	err = s.checkRevoked(c, parsed)
+	if err != nil {
+		return
+	}
+
+	return *parsed, nil
 }
 
 // Refresh access token given a refresh token.
-func (s service) Refresh(c *gin.Context, refreshToken string) (accessToken string, err error) {
+// Each refresh token can be used only once, a new pair of tokens is returned instead.
+func (s service) Refresh(c *gin.Context, refreshToken string) (accessToken, newRefreshToken string, err error) {
+	log := logger.Logger(c)
+
+	claims, err := tk.ParseToken(refreshToken)
+	if err != nil {
+		log.Info().Caller().Msg(err.Error())
+		return accessToken, newRefreshToken, e.ErrTokenInvalid
+	}
+
+	if claims.Type != tk.TypeRefresh || claims.Id == "" || claims.UserID == "" {
+		log.Info().Caller().Msg("token is not a refresh token")
+		return accessToken, newRefreshToken, e.ErrTokenInvalid
+	}
+
+	err = s.checkRevoked(c, claims)
+	if err != nil {
+		return
+	}
+
+	// Mark this refresh token as used until it expires.
+	// If it was marked before, someone is trying to use it again.
+	key := fmt.Sprintf(keyRefreshUsed, claims.Id)
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+
+	ok, err := s.cache.SetNX(c, key, claims.UserID, ttl).Result()
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return accessToken, newRefreshToken, e.ErrInternalServerError
+	}
+
+	if !ok {
+		log.Warn().Caller().Msg(fmt.Sprintf("refresh token '%s' from user '%s' was reused", claims.Id, claims.UserID))
+
+		// A reused refresh token can be a stolen one, so logout all sessions from this user.
+		if err := tk.RevokeUser(c, s.cache, claims.UserID); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+		return accessToken, newRefreshToken, e.ErrTokenRefreshReused
+	}
+
+	user := model.User{}
+
+	query := "SELECT id, created_at, updated_at, username, name, role, active FROM users WHERE id = $1"
+	err = s.db.QueryRowContext(c, query, claims.UserID).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.Username, &user.Name, &user.Role, &user.Active)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return accessToken, newRefreshToken, e.ErrUnauthorized
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return accessToken, newRefreshToken, e.ErrInternalServerError
+	}
+
+	if !user.Active {
+		log.Info().Caller().Msg(fmt.Sprintf("user '%s' is not active", user.ID))
+		return accessToken, newRefreshToken, e.ErrUnauthorized
+	}
+
+	accessToken, newRefreshToken, err = tk.GenerateJWTAccess(user)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return accessToken, newRefreshToken, e.ErrInternalServerError
+	}
+
+	return
+}
+
+// Logout revoke access token and, if sent, the refresh token from same user.
+func (s service) Logout(c *gin.Context, payload logoutRequest) (err error) {
 	log := logger.Logger(c)
 
-	log.Warn().Caller().Msg(err.Error())
-	err = e.ErrNotImplemented
+	claims, err := s.Valid(c, payload.AccessToken)
+	if err != nil {
+		return
+	}
+
+	err = tk.Revoke(c, s.cache, &claims)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if payload.RefreshToken == "" {
+		return
+	}
+
+	refresh, err := tk.ParseToken(payload.RefreshToken)
+	if err != nil || refresh.Type != tk.TypeRefresh || refresh.OwnerID() != claims.OwnerID() {
+		log.Info().Caller().Msg("refresh token is invalid or from another user, ignoring it")
+		return nil
+	}
+
+	err = tk.Revoke(c, s.cache, refresh)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	return
+}
+
+// LogoutAll revoke all tokens issued until now to the user from access token.
+func (s service) LogoutAll(c *gin.Context, accessToken string) (err error) {
+	log := logger.Logger(c)
+
+	claims, err := s.Valid(c, accessToken)
+	if err != nil {
+		return
+	}
+
+	err = tk.RevokeUser(c, s.cache, claims.OwnerID())
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	log.Info().Caller().Msg(fmt.Sprintf("all sessions from user '%s' were revoked", claims.OwnerID()))
+	return
+}
+
+// checkRevoked return an error if token was revoked by logout.
+func (s service) checkRevoked(c *gin.Context, claims *tk.JWTClaim) (err error) {
+	log := logger.Logger(c)
+
+	revoked, err := tk.IsRevoked(c, s.cache, claims)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if revoked {
+		log.Info().Caller().Msg(fmt.Sprintf("token '%s' was revoked", claims.Id))
+		return e.ErrTokenRevoked
+	}
+
 	return
 }
@@ -1,9 +1,11 @@
 package token
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
-	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/response"
 )
 
 func (s service) NewHTTP(rg *gin.RouterGroup) {
@@ -11,18 +13,70 @@ func (s service) NewHTTP(rg *gin.RouterGroup) {
 
 	r.GET("/valid", s.HTTPValid)
 	r.POST("/refresh", s.HTTPRefresh)
+	r.POST("/logout", s.HTTPLogout)
+	r.POST("/logout/all", s.HTTPLogoutAll)
 }
 
 func (s service) HTTPValid(c *gin.Context) {
-	log := logger.Logger(c)
+	d, err := decodeValid(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	claims, err := s.Valid(c, d.AccessToken)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
 
-	err := e.ErrNotImplemented
-	log.Warn().Caller().Msg(err.Error())
+	response.Default(c, encodeValid(claims), "", http.StatusOK)
 }
 
 func (s service) HTTPRefresh(c *gin.Context) {
-	log := logger.Logger(c)
+	d, err := decodeRefresh(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	accessToken, refreshToken, err := s.Refresh(c, d.RefreshToken)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeRefresh(accessToken, refreshToken), "", http.StatusOK)
+}
+
+func (s service) HTTPLogout(c *gin.Context) {
+	d, err := decodeLogout(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.Logout(c, d)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
+
+func (s service) HTTPLogoutAll(c *gin.Context) {
+	d, err := decodeLogoutAll(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.LogoutAll(c, d.AccessToken)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
 
-	err := e.ErrNotImplemented
-	log.Warn().Caller().Msg(err.Error())
+	response.Default(c, nil, "", http.StatusOK)
 }
@@ -1,13 +1,82 @@
 package click
 
 import (
+	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wvoliveira/corgi/internal/pkg/common"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 )
 
-func decodeFind(c *gin.Context) (link string) {
-	link = c.Param("link")
-	link = strings.Replace(link, "_", "/", -1)
-	return
+type findRequest struct {
+	WhoID         string
+	Domain        string
+	Keyword       string
+	Page          int
+	Offset        int
+	Limit         int
+	TimestampFrom time.Time
+	TimestampTo   time.Time
+}
+
+// decodeFind get short link from path like "domain_keyword".
+// Only the first underscore is a separator, because keywords can have it too.
+func decodeFind(c *gin.Context) (req findRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	link := c.Param("link")
+	link = strings.Replace(link, "_", "/", 1)
+
+	req.Domain, req.Keyword = common.SplitURL(link)
+	if req.Domain == "" || req.Keyword == "" {
+		return req, e.ErrLinkNotFound
+	}
+
+	page := 1
+	limit := 10
+
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "10")); err == nil {
+		limit = l
+		switch {
+		case limit > 100:
+			limit = 100
+		case limit <= 0:
+			limit = 10
+		}
+	}
+
+	req.TimestampTo = time.Now()
+
+	if tsf := c.Query("tsf"); tsf != "" {
+		if req.TimestampFrom, err = time.Parse(time.RFC3339, tsf); err != nil {
+			return req, e.ErrClickInvalidTimestamp
+		}
+	}
+
+	if tst := c.Query("tst"); tst != "" {
+		if req.TimestampTo, err = time.Parse(time.RFC3339, tst); err != nil {
+			return req, e.ErrClickInvalidTimestamp
+		}
+	}
+
+	// Clicks are saved in UTC and database drops the offset, so compare in UTC too.
+	req.TimestampFrom = req.TimestampFrom.UTC()
+	req.TimestampTo = req.TimestampTo.UTC()
+
+	req.WhoID = v.(string)
+	req.Page = page
+	req.Limit = limit
+	req.Offset = (page - 1) * limit
+	return req, nil
 }
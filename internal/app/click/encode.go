@@ -1 +1,28 @@
 package click
+
+import (
+	"time"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+type findResponse struct {
+	Clicks        []model.Click `json:"clicks"`
+	Limit         int           `json:"limit"`
+	Page          int           `json:"page"`
+	Total         int64         `json:"total"`
+	Pages         int           `json:"pages"`
+	TimestampFrom time.Time     `json:"timestamp_from"`
+	TimestampTo   time.Time     `json:"timestamp_to"`
+}
+
+type countryModel struct {
+	Country string `json:"country"`
+	Total   int64  `json:"total"`
+}
+
+type countriesResponse struct {
+	Countries     []countryModel `json:"countries"`
+	TimestampFrom time.Time      `json:"timestamp_from"`
+	TimestampTo   time.Time      `json:"timestamp_to"`
+}
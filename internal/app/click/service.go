@@ -2,17 +2,23 @@ package click
 
 import (
 	"database/sql"
+	"errors"
+	"math"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
 )
 
 type Service interface {
-	Find(*gin.Context, string) ([]string, error)
+	Find(*gin.Context, findRequest) (int64, int, []model.Click, error)
+	Countries(*gin.Context, findRequest) ([]countryModel, error)
 
 	NewHTTP(*gin.RouterGroup)
 	HTTPFind(*gin.Context)
+	HTTPCountries(*gin.Context)
 }
 
 type service struct {
@@ -25,45 +31,132 @@ func NewService(db *sql.DB, cache *redis.Client) Service {
 	return service{db, cache}
 }
 
-// FindAll get a list of links from database.
-func (s service) Find(c *gin.Context, link string) (clicks []string, err error) {
-
+// Find get a list of click events from a link, newest first.
+func (s service) Find(c *gin.Context, payload findRequest) (total int64, pages int, clicks []model.Click, err error) {
 	log := logger.Logger(c)
 
-	// key := fmt.Sprintf("link_%s_click_", link)
+	linkID, err := s.findLinkID(c, payload)
+	if err != nil {
+		return
+	}
+
+	queryCount := `SELECT COUNT(0) FROM clicks
+		WHERE link_id = $1 AND created_at >= $2 AND created_at <= $3
+	`
+	log.Debug().Caller().Msg(queryCount)
 
-	// err = s.cache.View(func(txn *badger.Txn) error {
+	err = s.db.QueryRowContext(c, queryCount, linkID, payload.TimestampFrom, payload.TimestampTo).Scan(&total)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, pages, clicks, e.ErrInternalServerError
+	}
+
+	queryData := `SELECT id, created_at, referer, user_agent, ip, language,
+			COALESCE(country, ''), COALESCE(state, ''), COALESCE(city, ''), link_id
+		FROM clicks
+		WHERE link_id = $1 AND created_at >= $2 AND created_at <= $3
+		ORDER BY created_at DESC OFFSET $4 LIMIT $5
+	`
+	log.Debug().Caller().Msg(queryData)
+
+	rows, err := s.db.QueryContext(
+		c,
+		queryData,
+		linkID,
+		payload.TimestampFrom,
+		payload.TimestampTo,
+		payload.Offset,
+		payload.Limit,
+	)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, pages, clicks, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	clicks = []model.Click{}
+	click := model.Click{}
+
+	for rows.Next() {
+		err = rows.Scan(
+			&click.ID,
+			&click.Timestamp,
+			&click.Referer,
+			&click.UserAgent,
+			&click.IP,
+			&click.Language,
+			&click.Country,
+			&click.State,
+			&click.City,
+			&click.LinkID,
+		)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return total, pages, clicks, e.ErrInternalServerError
+		}
+
+		clicks = append(clicks, click)
+	}
 
-	// 	it := txn.NewIterator(badger.DefaultIteratorOptions)
-	// 	prefix := []byte(key)
+	pages = int(math.Ceil(float64(total) / float64(payload.Limit)))
+	return
+}
 
-	// 	defer it.Close()
+// Countries get total of clicks by country from a link, most clicked first.
+// Clicks without location are grouped with a blank country.
+func (s service) Countries(c *gin.Context, payload findRequest) (countries []countryModel, err error) {
+	log := logger.Logger(c)
 
-	// 	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+	linkID, err := s.findLinkID(c, payload)
+	if err != nil {
+		return
+	}
 
-	// 		item := it.Item()
-	// 		k := item.Key()
+	query := `SELECT COALESCE(country, ''), COUNT(0) AS total FROM clicks
+		WHERE link_id = $1 AND created_at >= $2 AND created_at <= $3
+		GROUP BY COALESCE(country, '')
+		ORDER BY total DESC
+	`
+	log.Debug().Caller().Msg(query)
 
-	// 		err := item.Value(func(v []byte) error {
+	rows, err := s.db.QueryContext(c, query, linkID, payload.TimestampFrom, payload.TimestampTo)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return countries, e.ErrInternalServerError
+	}
 
-	// 			ts := strings.Split(string(k), key)[1]
+	defer rows.Close()
+	countries = []countryModel{}
+	country := countryModel{}
 
-	// 			clicks = append(clicks, ts)
+	for rows.Next() {
+		err = rows.Scan(&country.Country, &country.Total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return countries, e.ErrInternalServerError
+		}
 
-	// 			return nil
-	// 		})
+		countries = append(countries, country)
+	}
 
-	// 		if err != nil {
-	// 			return err
-	// 		}
+	return
+}
 
-	// 	}
+// findLinkID get link ID by domain and keyword, only if link is from who is asking.
+func (s service) findLinkID(c *gin.Context, payload findRequest) (linkID string, err error) {
+	log := logger.Logger(c)
 
-	// 	return nil
-	// })
+	query := "SELECT id FROM links WHERE user_id = $1 AND domain = $2 AND keyword = $3"
+	log.Debug().Caller().Msg(query)
 
+	err = s.db.QueryRowContext(c, query, payload.WhoID, payload.Domain, payload.Keyword).Scan(&linkID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return linkID, e.ErrLinkNotFound
+		}
+
 		log.Error().Caller().Msg(err.Error())
+		return linkID, e.ErrInternalServerError
 	}
 
 	return
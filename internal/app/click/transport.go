@@ -14,19 +14,54 @@ func (s service) NewHTTP(rg *gin.RouterGroup) {
 	r.Use(middleware.Checks())
 
 	r.GET("/:link", s.HTTPFind)
+	r.GET("/:link/countries", s.HTTPCountries)
 
 }
 
 func (s service) HTTPFind(c *gin.Context) {
+	payload, err := decodeFind(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	total, pages, clicks, err := s.Find(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
 
-	link := decodeFind(c)
+	resp := findResponse{
+		Clicks:        clicks,
+		Limit:         payload.Limit,
+		Page:          payload.Page,
+		Total:         total,
+		Pages:         pages,
+		TimestampFrom: payload.TimestampFrom,
+		TimestampTo:   payload.TimestampTo,
+	}
 
-	data, err := s.Find(c, link)
+	response.Default(c, resp, "", http.StatusOK)
+}
 
+func (s service) HTTPCountries(c *gin.Context) {
+	payload, err := decodeFind(c)
 	if err != nil {
 		e.EncodeError(c, err)
 		return
 	}
 
-	response.Default(c, data, "", http.StatusOK)
+	countries, err := s.Countries(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	resp := countriesResponse{
+		Countries:     countries,
+		TimestampFrom: payload.TimestampFrom,
+		TimestampTo:   payload.TimestampTo,
+	}
+
+	response.Default(c, resp, "", http.StatusOK)
 }
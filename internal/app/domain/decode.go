@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+)
+
+type addRequest struct {
+	WhoID   string
+	Name    string `json:"name" binding:"required"`
+	GroupID string `json:"group_id"`
+}
+
+type listRequest struct {
+	WhoID string
+}
+
+type findByIDRequest struct {
+	WhoID    string
+	DomainID string `uri:"id" binding:"required"`
+}
+
+type verifyRequest struct {
+	WhoID    string
+	DomainID string `uri:"id" binding:"required"`
+	Method   string `json:"method"`
+}
+
+type deleteRequest struct {
+	WhoID    string
+	DomainID string `uri:"id" binding:"required"`
+}
+
+func decodeAdd(c *gin.Context) (req addRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+	req.Name = normalize(req.Name)
+	return req, nil
+}
+
+func decodeList(c *gin.Context) (req listRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeFindByID(c *gin.Context) (req findByIDRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get domain id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+// decodeVerify get verification method from body. Without body, DNS is used.
+func decodeVerify(c *gin.Context) (req verifyRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get domain id from path")
+	}
+
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			return req, err
+		}
+	}
+
+	req.Method = strings.ToLower(req.Method)
+	if req.Method == "" {
+		req.Method = VerifyDNS
+	}
+
+	if req.Method != VerifyDNS && req.Method != VerifyHTTP {
+		return req, e.ErrDomainInvalidMethod
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeDelete(c *gin.Context) (req deleteRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get domain id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// domainResponse is a domain with instructions to prove its ownership.
+type domainResponse struct {
+	model.Domain
+	Verified     bool         `json:"verified"`
+	Instructions instructions `json:"instructions"`
+}
+
+type instructions struct {
+	DNS  dnsInstruction  `json:"dns"`
+	HTTP httpInstruction `json:"http"`
+}
+
+type dnsInstruction struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type httpInstruction struct {
+	URL     string `json:"url"`
+	Content string `json:"content"`
+}
+
+type listResponse struct {
+	Domains []domainResponse `json:"domains"`
+}
+
+func encodeDomain(d model.Domain) (r domainResponse) {
+	r.Domain = d
+	r.Verified = d.VerifiedAt != nil
+
+	r.Instructions = instructions{
+		DNS: dnsInstruction{
+			Type:  "TXT",
+			Name:  dnsRecordPrefix + hostname(d.Name),
+			Value: txtValuePrefix + d.Token,
+		},
+		HTTP: httpInstruction{
+			URL:     fmt.Sprintf("http://%s%s", d.Name, wellKnownPath),
+			Content: d.Token,
+		},
+	}
+	return
+}
+
+func encodeDomains(domains []model.Domain) (r listResponse) {
+	r.Domains = []domainResponse{}
+	for _, d := range domains {
+		r.Domains = append(r.Domains, encodeDomain(d))
+	}
+	return
+}
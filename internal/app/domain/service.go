@@ -0,0 +1,318 @@
+package domain
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// Domains from user or from any group of user.
+const ownerFilter = "(user_id = $1 OR group_id IN (SELECT group_id FROM group_user WHERE user_id = $1))"
+
+const uniqueViolation = "23505" // Postgres error code for duplicated unique key.
+
+const domainColumns = "id, created_at, updated_at, verified_at, name, token, user_id, group_id, created_by"
+
+// Service encapsulates the custom domain logic, http handlers and another transport layer.
+type Service interface {
+	Add(*gin.Context, addRequest) (model.Domain, error)
+	List(*gin.Context, listRequest) ([]model.Domain, error)
+	FindByID(*gin.Context, findByIDRequest) (model.Domain, error)
+	Verify(*gin.Context, verifyRequest) (model.Domain, error)
+	Delete(*gin.Context, deleteRequest) error
+
+	NewHTTP(*gin.RouterGroup)
+	HTTPAdd(*gin.Context)
+	HTTPList(*gin.Context)
+	HTTPFindByID(*gin.Context)
+	HTTPVerify(*gin.Context)
+	HTTPDelete(*gin.Context)
+}
+
+type service struct {
+	db    *sql.DB
+	cache *redis.Client
+}
+
+// NewService creates a new domain service.
+func NewService(db *sql.DB, cache *redis.Client) Service {
+	return service{db, cache}
+}
+
+// Add register a domain to user or to a group of user.
+// It can be used in links only after verification.
+func (s service) Add(c *gin.Context, payload addRequest) (d model.Domain, err error) {
+	log := logger.Logger(c)
+
+	if err = checkDomain(payload.Name); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if payload.GroupID != "" {
+		member := 0
+		query := "SELECT COUNT(0) FROM group_user WHERE group_id = $1 AND user_id = $2"
+
+		err = s.db.QueryRowContext(c, query, payload.GroupID, payload.WhoID).Scan(&member)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return d, e.ErrInternalServerError
+		}
+
+		if member == 0 {
+			return d, e.ErrGroupNotFound
+		}
+	}
+
+	// Verified by someone or already claimed by this user.
+	exists := 0
+	query := "SELECT COUNT(0) FROM domains WHERE name = $1 AND (verified_at IS NOT NULL OR created_by = $2)"
+
+	err = s.db.QueryRowContext(c, query, payload.Name, payload.WhoID).Scan(&exists)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	if exists > 0 {
+		return d, e.ErrDomainAlreadyExists
+	}
+
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	d.ID = ulid.Make().String()
+	d.CreatedAt = time.Now()
+	d.Name = payload.Name
+	d.Token = hex.EncodeToString(b)
+	d.CreatedBy = payload.WhoID
+
+	if payload.GroupID != "" {
+		d.GroupID = payload.GroupID
+		d.GroupIDNull = sql.NullString{String: payload.GroupID, Valid: true}
+	} else {
+		d.UserID = payload.WhoID
+		d.UserIDNull = sql.NullString{String: payload.WhoID, Valid: true}
+	}
+
+	query = `INSERT INTO domains(id, created_at, name, token, user_id, group_id, created_by)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = s.db.ExecContext(c, query, d.ID, d.CreatedAt, d.Name, d.Token, d.UserIDNull, d.GroupIDNull, d.CreatedBy)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	return d, nil
+}
+
+// List get domains from user and from groups of user.
+func (s service) List(c *gin.Context, payload listRequest) (domains []model.Domain, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + domainColumns + " FROM domains WHERE " + ownerFilter + " ORDER BY name ASC"
+
+	rows, err := s.db.QueryContext(c, query, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return domains, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	domains = []model.Domain{}
+
+	for rows.Next() {
+		d := model.Domain{}
+
+		if err = scanDomain(rows, &d); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return domains, e.ErrInternalServerError
+		}
+
+		domains = append(domains, d)
+	}
+
+	return domains, rows.Err()
+}
+
+// FindByID get a domain from user or from groups of user.
+func (s service) FindByID(c *gin.Context, payload findByIDRequest) (d model.Domain, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + domainColumns + " FROM domains WHERE id = $2 AND " + ownerFilter
+
+	err = scanDomain(s.db.QueryRowContext(c, query, payload.WhoID, payload.DomainID), &d)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d, e.ErrDomainNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+	return
+}
+
+// Verify check ownership of domain with DNS TXT record or HTTP well-known file.
+func (s service) Verify(c *gin.Context, payload verifyRequest) (d model.Domain, err error) {
+	log := logger.Logger(c)
+
+	d, err = s.FindByID(c, findByIDRequest{WhoID: payload.WhoID, DomainID: payload.DomainID})
+	if err != nil {
+		return
+	}
+
+	if d.VerifiedAt != nil {
+		return d, nil
+	}
+
+	switch payload.Method {
+	case VerifyHTTP:
+		err = verifyHTTP(c, d.Name, d.Token)
+	default:
+		err = verifyDNS(c, d.Name, d.Token)
+	}
+
+	if err != nil {
+		log.Info().Caller().Msg(fmt.Sprintf("domain '%s' not verified: %s", d.Name, err.Error()))
+		return d, e.ErrDomainVerificationFailed
+	}
+
+	now := time.Now()
+
+	tx, err := s.db.BeginTx(c, nil)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+	defer tx.Rollback()
+
+	// Unique index of verified names reject it if another claim was verified first.
+	_, err = tx.ExecContext(c, "UPDATE domains SET verified_at = $1, updated_at = $1 WHERE id = $2", now, d.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return d, e.ErrDomainAlreadyExists
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	// Pending claims of other users will never be verified now.
+	_, err = tx.ExecContext(c, "DELETE FROM domains WHERE name = $1 AND verified_at IS NULL", d.Name)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return d, e.ErrInternalServerError
+	}
+
+	d.VerifiedAt = &now
+	d.UpdatedAt = &now
+
+	log.Info().Caller().Msg(fmt.Sprintf("domain '%s' verified with %s", d.Name, payload.Method))
+	return d, nil
+}
+
+// Delete remove a domain without links.
+func (s service) Delete(c *gin.Context, payload deleteRequest) (err error) {
+	log := logger.Logger(c)
+
+	d, err := s.FindByID(c, findByIDRequest(payload))
+	if err != nil {
+		return
+	}
+
+	// Links with the domain name are from the verified owner, not from pending claims.
+	if d.VerifiedAt != nil {
+		links := 0
+		err = s.db.QueryRowContext(c, "SELECT COUNT(0) FROM links WHERE domain = $1", d.Name).Scan(&links)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+
+		if links > 0 {
+			return e.ErrDomainInUse
+		}
+	}
+
+	_, err = s.db.ExecContext(c, "DELETE FROM domains WHERE id = $1", d.ID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+	return
+}
+
+// checkDomain validate a hostname, with optional port.
+// Default and alternative domains are from everyone, so nobody can register them.
+func checkDomain(name string) (err error) {
+	err = validation.Validate(hostname(name), validation.Required, is.DNSName)
+	if err != nil {
+		return e.ErrDomainInvalid
+	}
+
+	if name == viper.GetString("DOMAIN_DEFAULT") {
+		return e.ErrDomainAlreadyExists
+	}
+
+	for _, alternative := range viper.GetStringSlice("DOMAIN_ALTERNATIVES") {
+		if name == alternative {
+			return e.ErrDomainAlreadyExists
+		}
+	}
+	return nil
+}
+
+// scanDomain read a row from domainColumns and fill nullable fields.
+func scanDomain(row interface{ Scan(...any) error }, d *model.Domain) (err error) {
+	err = row.Scan(
+		&d.ID,
+		&d.CreatedAt,
+		&d.UpdatedAtNull,
+		&d.VerifiedAtNull,
+		&d.Name,
+		&d.Token,
+		&d.UserIDNull,
+		&d.GroupIDNull,
+		&d.CreatedBy,
+	)
+	if err != nil {
+		return
+	}
+
+	if d.UpdatedAtNull.Valid {
+		d.UpdatedAt = &d.UpdatedAtNull.Time
+	}
+
+	if d.VerifiedAtNull.Valid {
+		d.VerifiedAt = &d.VerifiedAtNull.Time
+	}
+
+	d.UserID = d.UserIDNull.String
+	d.GroupID = d.GroupIDNull.String
+	return
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/response"
+)
+
+func (s service) NewHTTP(rg *gin.RouterGroup) {
+	r := rg.Group("/domains")
+
+	r.POST("", s.HTTPAdd)
+	r.GET("", s.HTTPList)
+	r.GET("/:id", s.HTTPFindByID)
+	r.POST("/:id/verify", s.HTTPVerify)
+	r.DELETE("/:id", s.HTTPDelete)
+}
+
+func (s service) HTTPAdd(c *gin.Context) {
+	payload, err := decodeAdd(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	domain, err := s.Add(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeDomain(domain), "", http.StatusCreated)
+}
+
+func (s service) HTTPList(c *gin.Context) {
+	payload, err := decodeList(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	domains, err := s.List(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeDomains(domains), "", http.StatusOK)
+}
+
+func (s service) HTTPFindByID(c *gin.Context) {
+	payload, err := decodeFindByID(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	domain, err := s.FindByID(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeDomain(domain), "", http.StatusOK)
+}
+
+func (s service) HTTPVerify(c *gin.Context) {
+	payload, err := decodeVerify(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	domain, err := s.Verify(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeDomain(domain), "", http.StatusOK)
+}
+
+func (s service) HTTPDelete(c *gin.Context) {
+	payload, err := decodeDelete(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.Delete(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// VerifyDNS check a TXT record like: _corgi-verification.go.example.com TXT "corgi-verification=<token>"
+	VerifyDNS = "dns"
+	// VerifyHTTP check a file like: http://go.example.com/.well-known/corgi-verification with "<token>"
+	VerifyHTTP = "http"
+
+	dnsRecordPrefix = "_corgi-verification."
+	txtValuePrefix  = "corgi-verification="
+	wellKnownPath   = "/.well-known/corgi-verification"
+
+	verifyTimeout = 10 * time.Second
+)
+
+// resolver use the DNS server from DOMAIN_VERIFY_RESOLVER, like "127.0.0.1:5353".
+// Without it, the system resolver is used.
+func resolver() *net.Resolver {
+	address := viper.GetString("DOMAIN_VERIFY_RESOLVER")
+	if address == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: verifyTimeout}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// verifyDNS look for the token in TXT records of domain.
+func verifyDNS(ctx context.Context, name, token string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	record := dnsRecordPrefix + hostname(name)
+
+	values, err := resolver().LookupTXT(ctx, record)
+	if err != nil {
+		return fmt.Errorf("lookup TXT '%s': %w", record, err)
+	}
+
+	for _, value := range values {
+		if strings.TrimSpace(value) == txtValuePrefix+token {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("token not found in TXT '%s'", record)
+}
+
+// verifyHTTP look for the token in well-known file of domain.
+// Redirects are not followed, the file must be in the domain itself.
+// The domain is from user, so only public addresses are requested, never our internal network,
+// except networks in DOMAIN_VERIFY_HTTP_ALLOW.
+func verifyHTTP(ctx context.Context, name, token string) (err error) {
+	if net.ParseIP(hostname(name)) != nil {
+		return fmt.Errorf("'%s' is an IP address, not a domain", name)
+	}
+
+	allowed, err := allowedNetworks()
+	if err != nil {
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: verifyTimeout, Resolver: resolver(), Control: publicOnly(allowed)}
+
+	client := http.Client{
+		Timeout:   verifyTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	url := fmt.Sprintf("http://%s%s", name, wellKnownPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("'%s' returned status %d", url, resp.StatusCode)
+	}
+
+	// Token is small, don't read big files.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return
+	}
+
+	if strings.TrimSpace(string(body)) != token {
+		return fmt.Errorf("token not found in '%s'", url)
+	}
+	return nil
+}
+
+// allowedNetworks parse DOMAIN_VERIFY_HTTP_ALLOW, like "127.0.0.1/32 10.0.0.0/8".
+// Without it, no internal network is allowed.
+func allowedNetworks() (networks []*net.IPNet, err error) {
+	for _, cidr := range viper.GetStringSlice("DOMAIN_VERIFY_HTTP_ALLOW") {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("DOMAIN_VERIFY_HTTP_ALLOW: %w", err)
+		}
+		networks = append(networks, network)
+	}
+	return
+}
+
+// publicOnly refuse connections to loopback, private, link-local and other non-public addresses,
+// unless they are in one of allowed networks.
+// It runs after the name is resolved, so DNS records pointing to internal hosts are refused too.
+func publicOnly(allowed []*net.IPNet) func(string, string, syscall.RawConn) error {
+	return func(_, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return fmt.Errorf("address '%s' is not public", host)
+		}
+
+		for _, network := range allowed {
+			if network.Contains(ip) {
+				return nil
+			}
+		}
+
+		if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+			return fmt.Errorf("address '%s' is not public", host)
+		}
+		return nil
+	}
+}
+
+// normalize domain name, so "Go.Example.com." and "go.example.com" are the same.
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.TrimSuffix(name, ".")
+}
+
+// hostname remove port from domain name, if any.
+func hostname(name string) string {
+	if host, _, err := net.SplitHostPort(name); err == nil {
+		return host
+	}
+	return name
+}
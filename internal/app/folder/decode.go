@@ -0,0 +1,114 @@
+package folder
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type addRequest struct {
+	WhoID string
+	Name  string `json:"name" binding:"required"`
+}
+
+type listRequest struct {
+	WhoID string
+}
+
+type findByIDRequest struct {
+	WhoID    string
+	FolderID string `uri:"id" binding:"required"`
+}
+
+type updateRequest struct {
+	WhoID    string
+	FolderID string  `uri:"id" binding:"required"`
+	Name     *string `json:"name"`
+}
+
+type deleteRequest struct {
+	WhoID    string
+	FolderID string `uri:"id" binding:"required"`
+}
+
+func decodeAdd(c *gin.Context) (req addRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+	req.Name = strings.TrimSpace(req.Name)
+	return req, nil
+}
+
+func decodeList(c *gin.Context) (req listRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeFindByID(c *gin.Context) (req findByIDRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get folder id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeUpdate(c *gin.Context) (req updateRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get folder id from path")
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeDelete(c *gin.Context) (req deleteRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get folder id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
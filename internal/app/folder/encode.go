@@ -0,0 +1,7 @@
+package folder
+
+import "github.com/wvoliveira/corgi/internal/pkg/model"
+
+type listResponse struct {
+	Folders []model.Folder `json:"folders"`
+}
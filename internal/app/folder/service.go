@@ -0,0 +1,194 @@
+package folder
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	maxNameLength = 50
+
+	// Postgres error code for unique violation.
+	uniqueViolation = "23505"
+)
+
+const folderColumns = `id, created_at, updated_at, name, user_id,
+	(SELECT COUNT(0) FROM links WHERE folder_id = folders.id AND deleted_at IS NULL)`
+
+// Service encapsulates the folder logic, http handlers and another transport layer.
+type Service interface {
+	Add(*gin.Context, addRequest) (model.Folder, error)
+	List(*gin.Context, listRequest) ([]model.Folder, error)
+	FindByID(*gin.Context, findByIDRequest) (model.Folder, error)
+	Update(*gin.Context, updateRequest) (model.Folder, error)
+	Delete(*gin.Context, deleteRequest) error
+
+	NewHTTP(*gin.RouterGroup)
+	HTTPAdd(*gin.Context)
+	HTTPList(*gin.Context)
+	HTTPFindByID(*gin.Context)
+	HTTPUpdate(*gin.Context)
+	HTTPDelete(*gin.Context)
+}
+
+type service struct {
+	db    *sql.DB
+	cache *redis.Client
+}
+
+// NewService creates a new folder service.
+func NewService(db *sql.DB, cache *redis.Client) Service {
+	return service{db, cache}
+}
+
+// Add create a new folder to user.
+func (s service) Add(c *gin.Context, payload addRequest) (folder model.Folder, err error) {
+	if err = checkFolder(payload.Name); err != nil {
+		return
+	}
+
+	folder.ID = ulid.Make().String()
+	folder.CreatedAt = time.Now()
+	folder.Name = payload.Name
+	folder.UserID = payload.WhoID
+
+	query := "INSERT INTO folders(id, created_at, name, user_id) VALUES($1, $2, $3, $4)"
+
+	_, err = s.db.ExecContext(c, query, folder.ID, folder.CreatedAt, folder.Name, folder.UserID)
+	if err != nil {
+		return folder, encodeDatabaseError(c, err)
+	}
+	return
+}
+
+// List get all folders from user, by name.
+func (s service) List(c *gin.Context, payload listRequest) (folders []model.Folder, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + folderColumns + " FROM folders WHERE user_id = $1 ORDER BY name ASC"
+
+	rows, err := s.db.QueryContext(c, query, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return folders, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	folders = []model.Folder{}
+
+	for rows.Next() {
+		folder := model.Folder{}
+
+		if err = scanFolder(rows, &folder); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return folders, e.ErrInternalServerError
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, rows.Err()
+}
+
+// FindByID get a folder from user.
+func (s service) FindByID(c *gin.Context, payload findByIDRequest) (folder model.Folder, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + folderColumns + " FROM folders WHERE id = $1 AND user_id = $2"
+
+	err = scanFolder(s.db.QueryRowContext(c, query, payload.FolderID, payload.WhoID), &folder)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return folder, e.ErrFolderNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return folder, e.ErrInternalServerError
+	}
+	return
+}
+
+// Update change name from a folder.
+func (s service) Update(c *gin.Context, payload updateRequest) (folder model.Folder, err error) {
+	folder, err = s.FindByID(c, findByIDRequest{WhoID: payload.WhoID, FolderID: payload.FolderID})
+	if err != nil {
+		return
+	}
+
+	if payload.Name != nil {
+		folder.Name = *payload.Name
+	}
+
+	if err = checkFolder(folder.Name); err != nil {
+		return
+	}
+
+	now := time.Now()
+	query := "UPDATE folders SET name = $1, updated_at = $2 WHERE id = $3 AND user_id = $4"
+
+	_, err = s.db.ExecContext(c, query, folder.Name, now, folder.ID, payload.WhoID)
+	if err != nil {
+		return folder, encodeDatabaseError(c, err)
+	}
+
+	folder.UpdatedAt = &now
+	return
+}
+
+// Delete remove a folder. Links in this folder are kept, without folder.
+func (s service) Delete(c *gin.Context, payload deleteRequest) (err error) {
+	log := logger.Logger(c)
+
+	result, err := s.db.ExecContext(c, "DELETE FROM folders WHERE id = $1 AND user_id = $2", payload.FolderID, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return e.ErrFolderNotFound
+	}
+	return
+}
+
+// checkFolder validate name length.
+func checkFolder(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return e.ErrFolderInvalid
+	}
+	return nil
+}
+
+// encodeDatabaseError convert a duplicated name in e.ErrFolderAlreadyExists.
+func encodeDatabaseError(c *gin.Context, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return e.ErrFolderAlreadyExists
+	}
+
+	log := logger.Logger(c)
+	log.Error().Caller().Msg(err.Error())
+	return e.ErrInternalServerError
+}
+
+// scanFolder read a row from folderColumns and fill nullable fields.
+func scanFolder(row interface{ Scan(...any) error }, folder *model.Folder) (err error) {
+	err = row.Scan(&folder.ID, &folder.CreatedAt, &folder.UpdatedAtNull, &folder.Name, &folder.UserID, &folder.Links)
+	if err != nil {
+		return
+	}
+
+	if folder.UpdatedAtNull.Valid {
+		folder.UpdatedAt = &folder.UpdatedAtNull.Time
+	}
+	return
+}
@@ -3,6 +3,7 @@ package group
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/wvoliveira/corgi/internal/pkg/cursor"
 	"strconv"
 )
 
@@ -16,10 +17,11 @@ type addRequest struct {
 
 type listRequest struct {
 	WhoID  string
-	Page   int    `json:"page"`
-	Sort   string `json:"sort"`
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
+	Page   int           `json:"page"`
+	Sort   string        `json:"sort"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+	Cursor cursor.Cursor `json:"-"`
 }
 
 type findByIDRequest struct {
@@ -41,19 +43,21 @@ type invitesAddByIDRequest struct {
 
 type invitesListByIDRequest struct {
 	WhoID   string
-	GroupID string `uri:"id"`
-	Page    int    `form:"page"`
-	Sort    string `form:"sort"`
-	Offset  int    `form:"offset"`
-	Limit   int    `form:"limit"`
+	GroupID string        `uri:"id"`
+	Page    int           `form:"page"`
+	Sort    string        `form:"sort"`
+	Offset  int           `form:"offset"`
+	Limit   int           `form:"limit"`
+	Cursor  cursor.Cursor `form:"-"`
 }
 
 type invitesListRequest struct {
 	WhoID  string
-	Page   int    `form:"page"`
-	Sort   string `form:"sort"`
-	Offset int    `form:"offset"`
-	Limit  int    `form:"limit"`
+	Page   int           `form:"page"`
+	Sort   string        `form:"sort"`
+	Offset int           `form:"offset"`
+	Limit  int           `form:"limit"`
+	Cursor cursor.Cursor `form:"-"`
 }
 
 func decodeAdd(c *gin.Context) (req addRequest, err error) {
@@ -102,6 +106,8 @@ func decodeList(c *gin.Context) (req listRequest, err error) {
 	req.Sort = sort
 	req.Limit = limit
 	req.Offset = offset
+
+	req.Cursor, err = decodeCursor(c, &req.Page, &req.Offset)
 	return
 }
 
@@ -184,6 +190,8 @@ func decodeInvitesListByID(c *gin.Context) (req invitesListByIDRequest, err erro
 
 	req.GroupID = c.Param("id")
 	req.Offset = offset
+
+	req.Cursor, err = decodeCursor(c, &req.Page, &req.Offset)
 	return
 }
 
@@ -217,5 +225,18 @@ func decodeInvitesList(c *gin.Context) (req invitesListRequest, err error) {
 	offset := (req.Page - 1) * req.Limit
 
 	req.Offset = offset
+
+	req.Cursor, err = decodeCursor(c, &req.Page, &req.Offset)
+	return
+}
+
+// decodeCursor read the "cursor" query. With a cursor, page numbers are ignored.
+func decodeCursor(c *gin.Context, page, offset *int) (cur cursor.Cursor, err error) {
+	cur, err = cursor.Parse(c.Query("cursor"))
+	if err != nil || cur.IsZero() {
+		return
+	}
+
+	*page, *offset = 0, 0
 	return
 }
@@ -12,13 +12,16 @@ type addResponse struct {
 	Description string `json:"description"`
 }
 
+// listResponse is a page of groups. Page, total and pages are zero when
+// request uses a cursor, because cursors don't count rows.
 type listResponse struct {
-	Groups []model.Group `json:"groups"`
-	Limit  int           `json:"limit"`
-	Page   int           `json:"page"`
-	Sort   string        `json:"sort"`
-	Total  int64         `json:"total"`
-	Pages  int           `json:"pages"`
+	Groups     []model.Group `json:"groups"`
+	Limit      int           `json:"limit"`
+	Page       int           `json:"page"`
+	Sort       string        `json:"sort"`
+	Total      int64         `json:"total"`
+	Pages      int           `json:"pages"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 type findByIDUserModel struct {
@@ -44,12 +47,13 @@ type inviteModel struct {
 }
 
 type invitesListResponse struct {
-	Invites []inviteModel `json:"invites"`
-	Page    int           `json:"page"`
-	Pages   int           `json:"pages"`
-	Total   int64         `json:"total"`
-	Limit   int           `json:"limit"`
-	Sort    string        `json:"sort"`
+	Invites    []inviteModel `json:"invites"`
+	Page       int           `json:"page"`
+	Pages      int           `json:"pages"`
+	Total      int64         `json:"total"`
+	Limit      int           `json:"limit"`
+	Sort       string        `json:"sort"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 func encodeFindByID(c *gin.Context, group model.Group, users []model.User) (res findByIDResponse) {
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
+	"github.com/wvoliveira/corgi/internal/pkg/cursor"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
 	"github.com/wvoliveira/corgi/internal/pkg/model"
@@ -16,7 +17,7 @@ import (
 // Service encapsulates the link service logic, http handlers and another transport layer.
 type Service interface {
 	Add(*gin.Context, string, model.Group) (model.Group, error)
-	List(*gin.Context, string, int, int, string) (int64, int, []model.Group, error)
+	List(*gin.Context, listRequest) (listResponse, error)
 	FindByID(*gin.Context, string, string) (model.Group, []model.User, error)
 	Delete(*gin.Context, string, string) error
 	InvitesAddByID(*gin.Context, invitesAddByIDRequest) (model.GroupInvite, error)
@@ -109,35 +110,45 @@ func (s service) Add(c *gin.Context, whoID string, payload model.Group) (group m
 	return
 }
 
-func (s service) List(c *gin.Context, whoID string, offset, limit int, sort string) (total int64, pages int, groups []model.Group, err error) {
+func (s service) List(c *gin.Context, payload listRequest) (response listResponse, err error) {
 	log := logger.Logger(c)
 
-	sttCount, _ := s.db.PrepareContext(c, "SELECT COUNT(0) FROM group_user WHERE user_id = $1")
+	args := []any{payload.WhoID}
+	where := "u.id = $1"
 
-	err = sttCount.QueryRowContext(c, whoID).Scan(&total)
-	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return
+	// Total is only needed by page numbers, cursors skip the count.
+	if payload.Cursor.IsZero() {
+		sttCount, _ := s.db.PrepareContext(c, "SELECT COUNT(0) FROM group_user WHERE user_id = $1")
+
+		err = sttCount.QueryRowContext(c, payload.WhoID).Scan(&response.Total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+	} else {
+		args = append(args, payload.Cursor.ID)
+		where += " AND g.id > $2"
 	}
 
 	// TODO: fix to use "sort" variable
 	query := `SELECT g.* FROM groups g
 		INNER JOIN group_user gu ON gu.group_id = g.id
 		INNER JOIN users u ON u.id = gu.user_id
-		WHERE u.id = $1
-		ORDER BY g.id ASC OFFSET $2 LIMIT $3`
+		WHERE ` + where + fmt.Sprintf(`
+		ORDER BY g.id ASC OFFSET $%d LIMIT $%d`, len(args)+1, len(args)+2)
 
 	log.Debug().Caller().Msg(query)
 	sttData, _ := s.db.PrepareContext(c, query)
 
-	rows, err := sttData.QueryContext(c, whoID, offset, limit)
+	// One more row than limit tell us if there is a next page.
+	rows, err := sttData.QueryContext(c, append(args, payload.Offset, payload.Limit+1)...)
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
 		return
 	}
 
 	defer rows.Close()
-	groups = []model.Group{}
+	groups := []model.Group{}
 	group := model.Group{}
 
 	for rows.Next() {
@@ -156,11 +167,22 @@ func (s service) List(c *gin.Context, whoID string, offset, limit int, sort stri
 
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
-		return total, pages, groups, e.ErrInternalServerError
+		return response, e.ErrInternalServerError
 	}
 
-	pages = int(math.Ceil(float64(total) / float64(limit)))
+	if len(groups) > payload.Limit {
+		groups = groups[:payload.Limit]
+		response.NextCursor = cursor.Cursor{ID: groups[len(groups)-1].ID}.String()
+	}
+
+	if payload.Cursor.IsZero() {
+		response.Pages = int(math.Ceil(float64(response.Total) / float64(payload.Limit)))
+	}
 
+	response.Groups = groups
+	response.Page = payload.Page
+	response.Limit = payload.Limit
+	response.Sort = payload.Sort
 	return
 }
 
@@ -377,12 +399,20 @@ func (s service) InvitesListByID(c *gin.Context, payload invitesListByIDRequest)
 	log := logger.Logger(c)
 
 	total := int64(0)
+	args := []any{payload.WhoID, payload.GroupID}
+	where := "u.id = $1 AND g.id = $2"
 
-	sttCount, _ := s.db.PrepareContext(c, "SELECT COUNT(0) FROM group_user WHERE group_id = $1")
-	err = sttCount.QueryRowContext(c, payload.GroupID).Scan(&total)
-	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return
+	// Total is only needed by page numbers, cursors skip the count.
+	if payload.Cursor.IsZero() {
+		sttCount, _ := s.db.PrepareContext(c, "SELECT COUNT(0) FROM group_user WHERE group_id = $1")
+		err = sttCount.QueryRowContext(c, payload.GroupID).Scan(&total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+	} else {
+		args = append(args, payload.Cursor.ID)
+		where += " AND gi.id > $3"
 	}
 
 	// TODO: fix to use "sort" variable
@@ -391,14 +421,15 @@ func (s service) InvitesListByID(c *gin.Context, payload invitesListByIDRequest)
 				 INNER JOIN groups_invites gi on gi.group_id = g.id
 				 INNER JOIN users u on u.id = gi.user_id
 				 INNER JOIN users iu on iu.id = gi.invited_by
-		WHERE u.id = $1 AND g.id = $2
-		ORDER BY g.id ASC OFFSET $3 LIMIT $4
-	`
+		WHERE ` + where + fmt.Sprintf(`
+		ORDER BY gi.id ASC OFFSET $%d LIMIT $%d
+	`, len(args)+1, len(args)+2)
 
 	log.Debug().Caller().Msg(query)
 	sttData, _ := s.db.PrepareContext(c, query)
 
-	rows, err := sttData.QueryContext(c, payload.WhoID, payload.GroupID, payload.Offset, payload.Limit)
+	// One more row than limit tell us if there is a next page.
+	rows, err := sttData.QueryContext(c, append(args, payload.Offset, payload.Limit+1)...)
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
 		return
@@ -429,10 +460,16 @@ func (s service) InvitesListByID(c *gin.Context, payload invitesListByIDRequest)
 		return response, e.ErrInternalServerError
 	}
 
-	pages := int(math.Ceil(float64(total) / float64(payload.Limit)))
+	if len(response.Invites) > payload.Limit {
+		response.Invites = response.Invites[:payload.Limit]
+		response.NextCursor = cursor.Cursor{ID: response.Invites[len(response.Invites)-1].InviteID}.String()
+	}
+
+	if payload.Cursor.IsZero() {
+		response.Pages = int(math.Ceil(float64(total) / float64(payload.Limit)))
+	}
 
 	response.Page = payload.Page
-	response.Pages = pages
 	response.Total = total
 	response.Limit = payload.Limit
 	response.Sort = payload.Sort
@@ -451,11 +488,20 @@ func (s service) InvitesList(c *gin.Context, payload invitesListRequest) (respon
 		WHERE u.id = $1
 	`
 
-	sttCount, _ := s.db.PrepareContext(c, query)
-	err = sttCount.QueryRowContext(c, payload.WhoID).Scan(&total)
-	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return
+	args := []any{payload.WhoID}
+	where := "u.id = $1"
+
+	// Total is only needed by page numbers, cursors skip the count.
+	if payload.Cursor.IsZero() {
+		sttCount, _ := s.db.PrepareContext(c, query)
+		err = sttCount.QueryRowContext(c, payload.WhoID).Scan(&total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+	} else {
+		args = append(args, payload.Cursor.ID)
+		where += " AND gi.id > $2"
 	}
 
 	// TODO: fix to use "sort" variable
@@ -465,14 +511,15 @@ func (s service) InvitesList(c *gin.Context, payload invitesListRequest) (respon
 				 INNER JOIN groups_invites gi on gi.group_id = g.id
 				 INNER JOIN users u on u.id = gi.user_id
 				 INNER JOIN users iu on iu.id = gi.invited_by
-		WHERE u.id = $1
-		ORDER BY gi.id ASC OFFSET $2 LIMIT $3
-	`
+		WHERE ` + where + fmt.Sprintf(`
+		ORDER BY gi.id ASC OFFSET $%d LIMIT $%d
+	`, len(args)+1, len(args)+2)
 
 	log.Debug().Caller().Msg(query)
 	sttData, _ := s.db.PrepareContext(c, query)
 
-	rows, err := sttData.QueryContext(c, payload.WhoID, payload.Offset, payload.Limit)
+	// One more row than limit tell us if there is a next page.
+	rows, err := sttData.QueryContext(c, append(args, payload.Offset, payload.Limit+1)...)
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
 		return
@@ -503,10 +550,16 @@ func (s service) InvitesList(c *gin.Context, payload invitesListRequest) (respon
 		return response, e.ErrInternalServerError
 	}
 
-	pages := int(math.Ceil(float64(total) / float64(payload.Limit)))
+	if len(response.Invites) > payload.Limit {
+		response.Invites = response.Invites[:payload.Limit]
+		response.NextCursor = cursor.Cursor{ID: response.Invites[len(response.Invites)-1].InviteID}.String()
+	}
+
+	if payload.Cursor.IsZero() {
+		response.Pages = int(math.Ceil(float64(total) / float64(payload.Limit)))
+	}
 
 	response.Page = payload.Page
-	response.Pages = pages
 	response.Total = total
 	response.Limit = payload.Limit
 	response.Sort = payload.Sort
@@ -52,21 +52,12 @@ func (s service) HTTPList(c *gin.Context) {
 		return
 	}
 
-	total, pages, groups, err := s.List(c, d.WhoID, d.Offset, d.Limit, d.Sort)
+	resp, err := s.List(c, d)
 	if err != nil {
 		e.EncodeError(c, err)
 		return
 	}
 
-	resp := listResponse{
-		Groups: groups,
-		Limit:  d.Limit,
-		Page:   d.Page,
-		Sort:   d.Sort,
-		Total:  total,
-		Pages:  pages,
-	}
-
 	response.Default(c, resp, "", http.StatusOK)
 }
 
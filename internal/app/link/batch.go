@@ -0,0 +1,190 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// Each item runs inside a savepoint, so one bad item doesn't abort the transaction.
+const batchSavepoint = "batch_item"
+
+// AddBatch create many links in one transaction.
+// Invalid items are reported in results and the others are created anyway.
+func (s service) AddBatch(c *gin.Context, payload batchAddRequest) (results []batchResult, err error) {
+	results = make([]batchResult, len(payload.Links))
+
+	err = s.batch(c, len(payload.Links), func(tx *sql.Tx, i int) error {
+		item := payload.Links[i]
+		item.WhoID = payload.WhoID
+
+		newLink, err := s.newLink(c, item)
+		if err != nil {
+			return err
+		}
+
+		link, err := insertLink(c, tx, newLink)
+		if err != nil {
+			return err
+		}
+
+		results[i].ID = link.ID
+		results[i].Link = &link
+		return nil
+	}, results)
+
+	if err == nil {
+		s.uncache(c, batchLinks(results)...)
+	}
+	return
+}
+
+// batchLinks get links saved by a batch, from its results.
+func batchLinks(results []batchResult) (links []model.Link) {
+	for _, result := range results {
+		if result.Link != nil {
+			links = append(links, *result.Link)
+		}
+	}
+	return
+}
+
+// UpdateBatch change many links in one transaction, each one like Update.
+func (s service) UpdateBatch(c *gin.Context, payload batchUpdateRequest) (results []batchResult, err error) {
+	results = make([]batchResult, len(payload.Links))
+	olds := make([]model.Link, len(payload.Links))
+
+	err = s.batch(c, len(payload.Links), func(tx *sql.Tx, i int) error {
+		item := payload.Links[i]
+		results[i].ID = item.ID
+
+		old, link, changed, err := s.updateLink(c, tx, item.updateRequest)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			olds[i] = old
+		}
+
+		results[i].Link = &link
+		return nil
+	}, results)
+
+	if err != nil {
+		return
+	}
+
+	for i, old := range olds {
+		if old.ID != "" {
+			s.updated(c, old, *results[i].Link)
+		}
+	}
+	return
+}
+
+// DeleteBatch move many links to trash in one transaction and remove them from cache.
+func (s service) DeleteBatch(c *gin.Context, payload batchDeleteRequest) (results []batchResult, err error) {
+	log := logger.Logger(c)
+	results = make([]batchResult, len(payload.IDs))
+	deleted := []model.Link{}
+
+	query := `UPDATE links l SET active = false, deleted_at = $1, updated_at = $1 FROM links old
+		WHERE old.id = l.id AND l.id = $2 AND l.user_id = $3 AND l.deleted_at IS NULL
+		RETURNING l.domain, l.keyword, old.active`
+	log.Debug().Caller().Msg(query)
+
+	err = s.batch(c, len(payload.IDs), func(tx *sql.Tx, i int) error {
+		link := model.Link{ID: payload.IDs[i]}
+		results[i].ID = link.ID
+
+		err := tx.QueryRowContext(c, query, time.Now(), link.ID, payload.WhoID).Scan(&link.Domain, &link.Keyword, &link.Active)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return e.ErrLinkNotFound
+			}
+
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+
+		revisions := diffLink(link, model.Link{Domain: link.Domain, Keyword: link.Keyword, Active: "false"})
+		if err = insertRevisions(c, tx, link.ID, payload.WhoID, revisions); err != nil {
+			return err
+		}
+
+		deleted = append(deleted, link)
+		return nil
+	}, results)
+
+	if err != nil {
+		return
+	}
+
+	// Keep going on error from cache.
+	// Because SQL database still working, so cache doesn't matter at this moment.
+	for _, link := range deleted {
+		key := fmt.Sprintf(keyCacheShortLink, link.Domain, link.Keyword)
+		if err := s.cache.Del(c, key).Err(); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+	return
+}
+
+// batch run "fn" for each item inside one transaction. Errors from items are saved
+// in results and the item changes are rolled back to its savepoint.
+// Only errors from transaction itself are returned.
+func (s service) batch(c context.Context, total int, fn func(*sql.Tx, int) error, results []batchResult) (err error) {
+	log := logger.Logger(c)
+
+	tx, err := s.db.BeginTx(c, &sql.TxOptions{})
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for i := 0; i < total; i++ {
+		results[i].Index = i
+
+		if _, err = tx.ExecContext(c, "SAVEPOINT "+batchSavepoint); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+
+		errItem := fn(tx, i)
+		if errItem == nil {
+			if _, err = tx.ExecContext(c, "RELEASE SAVEPOINT "+batchSavepoint); err != nil {
+				log.Error().Caller().Msg(err.Error())
+				return e.ErrInternalServerError
+			}
+			continue
+		}
+
+		results[i].Error = errItem.Error()
+
+		if _, err = tx.ExecContext(c, "ROLLBACK TO SAVEPOINT "+batchSavepoint); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+	return
+}
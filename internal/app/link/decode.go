@@ -1,9 +1,20 @@
 package link
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/wvoliveira/corgi/internal/pkg/cursor"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+	"github.com/wvoliveira/corgi/internal/pkg/qr"
+	"github.com/wvoliveira/corgi/internal/pkg/request"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type addRequest struct {
@@ -12,6 +23,31 @@ type addRequest struct {
 	Keyword string `json:"keyword"`
 	URL     string `json:"url" binding:"required"`
 	Title   string `json:"title"`
+
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   int        `json:"max_clicks"`
+	FallbackURL string     `json:"fallback_url"`
+	Password    string     `json:"password"`
+
+	RedirectType string `json:"redirect_type"`
+	ForwardQuery bool   `json:"forward_query"`
+	ForwardPath  bool   `json:"forward_path"`
+
+	// UTM parameters of link. Empty ones are filled from preset, if any.
+	model.UTM
+	UTMPreset string `json:"utm_preset"`
+
+	Targets []model.LinkTarget `json:"targets"`
+
+	Variants      []model.LinkVariant `json:"variants"`
+	StickyVariant bool                `json:"sticky_variant"`
+
+	StartsAt       *time.Time          `json:"starts_at"`
+	EndsAt         *time.Time          `json:"ends_at"`
+	Schedule       *model.LinkSchedule `json:"schedule"`
+	UnavailableURL string              `json:"unavailable_url"`
+
+	FolderID string `json:"folder_id"`
 }
 
 type findByIDRequest struct {
@@ -27,12 +63,63 @@ type findAllRequest struct {
 	Limit        int
 	ShortenedURL string
 	SearchText   string
+	TagID        string
+	FolderID     string
+	Domain       string
+	Active       *bool
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	SortBy       string
+	Order        string
+	Cursor       cursor.Cursor
+	Trash        bool
 }
 
+// updateRequest change only fields sent by client, so nil is "not changed".
 type updateRequest struct {
-	WhoID  string
-	LinkID string `uri:"id" binding:"required"`
-	Title  string `json:"title" binding:"required"`
+	WhoID   string
+	LinkID  string  `uri:"id" binding:"required"`
+	Title   *string `json:"title"`
+	URL     *string `json:"url"`
+	Keyword *string `json:"keyword"`
+	Domain  *string `json:"domain"`
+	Active  *bool   `json:"active"`
+
+	RedirectType *string `json:"redirect_type"`
+	ForwardQuery *bool   `json:"forward_query"`
+	ForwardPath  *bool   `json:"forward_path"`
+
+	// Targets and variants replace all of link, an empty list remove them.
+	Targets       *[]model.LinkTarget  `json:"targets"`
+	Variants      *[]model.LinkVariant `json:"variants"`
+	StickyVariant *bool                `json:"sticky_variant"`
+
+	// Window and schedule are removed with null.
+	StartsAt       optional[time.Time]          `json:"starts_at"`
+	EndsAt         optional[time.Time]          `json:"ends_at"`
+	Schedule       optional[model.LinkSchedule] `json:"schedule"`
+	UnavailableURL *string                      `json:"unavailable_url"`
+
+	// Empty folder remove link from its folder.
+	FolderID *string `json:"folder_id"`
+}
+
+// optional is a JSON field that can be missing, null or a value, so PATCH can remove it.
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	o.Value = nil
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	o.Value = new(T)
+	return json.Unmarshal(b, o.Value)
 }
 
 type deleteRequest struct {
@@ -46,13 +133,88 @@ type findFullURLRequest struct {
 	WhoID   string
 	Keyword string `uri:"keyword" binding:"required"`
 	Domain  string
+	Click   model.Click
+
+	// Path after keyword and query string, forwarded to destination if link allows it.
+	Path  string
+	Query string
+
+	// Variant from cookie, for links with sticky variants.
+	Variant string
+}
+
+type unlockRequest struct {
+	Keyword  string      `json:"-" form:"-"`
+	Domain   string      `json:"-" form:"-"`
+	Password string      `json:"password" form:"password" binding:"required"`
+	Click    model.Click `json:"-" form:"-"`
+	Path     string      `json:"-" form:"-"`
+	Query    string      `json:"-" form:"-"`
+	Variant  string      `json:"-" form:"-"`
+}
+
+type batchAddRequest struct {
+	WhoID string
+	Links []addRequest `json:"links" binding:"required"`
+}
+
+// batchUpdateItem change a link like updateRequest, only fields in item are changed.
+type batchUpdateItem struct {
+	ID string `json:"id"`
+	updateRequest
+}
+
+type batchUpdateRequest struct {
+	WhoID string
+	Links []batchUpdateItem `json:"links" binding:"required"`
+}
+
+type batchDeleteRequest struct {
+	WhoID string
+	IDs   []string `json:"ids" binding:"required"`
+}
+
+type exportRequest struct {
+	WhoID   string
+	GroupID string
+	Format  string
+}
+
+type importRequest struct {
+	WhoID    string
+	Domain   string
+	Format   string
+	Conflict string
+	File     io.Reader
+}
+
+type qrRequest struct {
+	WhoID   string
+	LinkID  string `uri:"id"`
+	Keyword string
+	Domain  string
+	Scheme  string
+	Options qr.Options
+}
+
+type tagsRequest struct {
+	WhoID  string
+	LinkID string   `uri:"id" binding:"required"`
+	TagIDs []string `json:"tag_ids"`
+}
+
+type tagRemoveRequest struct {
+	WhoID  string
+	LinkID string `uri:"id" binding:"required"`
+	TagID  string `uri:"tag_id" binding:"required"`
 }
 
 type clicksRequest struct {
 	WhoID         string
 	ShortURL      string
-	TimestampFrom string
-	TimestampTo   string
+	TimestampFrom time.Time
+	TimestampTo   time.Time
+	Granularity   string
 }
 
 func decodeAdd(c *gin.Context) (req addRequest, err error) {
@@ -70,6 +232,8 @@ func decodeAdd(c *gin.Context) (req addRequest, err error) {
 		req.Domain = c.Request.Host
 	}
 
+	req.Domain = strings.ToLower(req.Domain)
+
 	req.WhoID = v.(string)
 	return req, nil
 }
@@ -121,11 +285,71 @@ func decodeFindAll(c *gin.Context) (req findAllRequest, err error) {
 
 	req.WhoID = v.(string)
 	req.Page = page
-	req.Sort = "id ASC"
 	req.Limit = limit
 	req.Offset = offset
-	req.ShortenedURL = c.Query("u")
-	req.SearchText = c.Query("q")
+	req.ShortenedURL = strings.ToLower(strings.TrimSpace(c.Query("u")))
+	req.SearchText = strings.TrimSpace(c.Query("q"))
+	req.TagID = c.Query("tag")
+	req.FolderID = c.Query("folder")
+	req.Domain = strings.ToLower(strings.TrimSpace(c.Query("domain")))
+
+	// Sort accept "field" or "-field" for descending order, or order param.
+	req.SortBy = strings.ToLower(c.DefaultQuery("sort", "id"))
+	req.Order = strings.ToLower(c.DefaultQuery("order", "asc"))
+
+	if strings.HasPrefix(req.SortBy, "-") {
+		req.SortBy = strings.TrimPrefix(req.SortBy, "-")
+		req.Order = "desc"
+	}
+
+	if _, ok := sortColumns[req.SortBy]; !ok {
+		return req, e.ErrLinkInvalidSort
+	}
+
+	if req.Order != "asc" && req.Order != "desc" {
+		return req, e.ErrLinkInvalidSort
+	}
+
+	req.Sort = req.SortBy + " " + strings.ToUpper(req.Order)
+
+	// Cursor is only valid with the same sort of the list that created it.
+	req.Cursor, err = cursor.Parse(c.Query("cursor"))
+	if err != nil {
+		return
+	}
+
+	if !req.Cursor.IsZero() {
+		if req.Cursor.Sort != req.Sort {
+			return req, e.ErrInvalidCursor
+		}
+		req.Page = 0
+	}
+
+	if active := c.Query("active"); active != "" {
+		a, err := strconv.ParseBool(active)
+		if err != nil {
+			return req, e.ErrLinkInvalidFilter
+		}
+		req.Active = &a
+	}
+
+	if from := c.Query("created_from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return req, e.ErrLinkInvalidFilter
+		}
+		t = t.UTC()
+		req.CreatedFrom = &t
+	}
+
+	if to := c.Query("created_to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return req, e.ErrLinkInvalidFilter
+		}
+		t = t.UTC()
+		req.CreatedTo = &t
+	}
 	return req, nil
 }
 
@@ -144,6 +368,18 @@ func decodeUpdate(c *gin.Context) (req updateRequest, err error) {
 		return req, err
 	}
 
+	if req.Title == nil && req.URL == nil && req.Keyword == nil && req.Domain == nil && req.Active == nil &&
+		req.RedirectType == nil && req.ForwardQuery == nil && req.ForwardPath == nil &&
+		req.Targets == nil && req.Variants == nil && req.StickyVariant == nil &&
+		!req.StartsAt.Set && !req.EndsAt.Set && !req.Schedule.Set && req.UnavailableURL == nil && req.FolderID == nil {
+		return req, e.ErrRequestNeedBody
+	}
+
+	if req.Domain != nil {
+		domain := strings.ToLower(strings.TrimSpace(*req.Domain))
+		req.Domain = &domain
+	}
+
 	req.WhoID = v.(string)
 	return req, nil
 }
@@ -174,22 +410,329 @@ func decodeFindByKeyword(c *gin.Context) (req findFullURLRequest, err error) {
 		return req, err
 	}
 
+	// Each custom domain has its own keywords, so resolve redirect by Host.
 	req.WhoID = v.(string)
-	req.Domain = c.Request.Host
+	req.Domain = strings.ToLower(c.Request.Host)
+	req.Path = c.Param("path")
+	req.Query = c.Request.URL.RawQuery
+	req.Variant, _ = c.Cookie(variantCookie)
+
+	// Click columns have no time zone, so all of them are in UTC.
+	req.Click = model.Click{
+		Timestamp: time.Now().UTC(),
+		Referer:   c.Request.Referer(),
+		UserAgent: c.Request.UserAgent(),
+		IP:        request.IP(c.Request),
+		Language:  c.GetHeader("Accept-Language"),
+	}
+	return req, nil
+}
+
+// decodeUnlock get password from a HTML form or a JSON payload.
+func decodeUnlock(c *gin.Context) (req unlockRequest, err error) {
+	findReq, err := decodeFindByKeyword(c)
+	if err != nil {
+		return
+	}
+
+	req.Keyword = findReq.Keyword
+	req.Domain = findReq.Domain
+	req.Click = findReq.Click
+	req.Path = findReq.Path
+	req.Query = findReq.Query
+	req.Variant = findReq.Variant
+
+	if err = c.ShouldBind(&req); err != nil {
+		return req, e.ErrLinkPasswordRequired
+	}
 	return req, nil
 }
 
+// decodeClicks get short URL, timestamps in RFC3339 format and granularity of series.
+// Without timestamps, the series is from the last 7 days until now.
 func decodeClicks(ctx *gin.Context) (req clicksRequest, err error) {
+	v, ok := ctx.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+
 	shortURL := ctx.Query("u")
 	timestampFrom := ctx.Query("tsf")
 	timestampTo := ctx.Query("tst")
+	granularity := ctx.DefaultQuery("granularity", granularityDay)
 
 	if shortURL == "" {
 		return req, errors.New("you need pass short URL with 'u' query param")
 	}
 
+	req.TimestampTo = time.Now()
+	if timestampTo != "" {
+		if req.TimestampTo, err = time.Parse(time.RFC3339, timestampTo); err != nil {
+			return req, e.ErrClickInvalidTimestamp
+		}
+	}
+
+	req.TimestampFrom = req.TimestampTo.AddDate(0, 0, -7)
+	if timestampFrom != "" {
+		if req.TimestampFrom, err = time.Parse(time.RFC3339, timestampFrom); err != nil {
+			return req, e.ErrClickInvalidTimestamp
+		}
+	}
+
+	// Clicks are saved in UTC and database drops the offset, so compare in UTC too.
+	req.TimestampFrom = req.TimestampFrom.UTC()
+	req.TimestampTo = req.TimestampTo.UTC()
+
+	switch granularity {
+	case granularityHour, granularityDay, granularityWeek, granularityMonth:
+	default:
+		return req, e.ErrLinkClicksInvalidGranularity
+	}
+
 	req.ShortURL = shortURL
-	req.TimestampFrom = timestampFrom
-	req.TimestampTo = timestampTo
+	req.Granularity = granularity
+	return
+}
+
+func decodeBatchAdd(c *gin.Context) (req batchAddRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+
+	if err = checkBatch(req.WhoID, len(req.Links), viper.GetInt("LINKS_BATCH_MAX")); err != nil {
+		return
+	}
+
+	for i := range req.Links {
+		if req.Links[i].Domain == "" {
+			req.Links[i].Domain = c.Request.Host
+		}
+
+		req.Links[i].Domain = strings.ToLower(req.Links[i].Domain)
+	}
+	return req, nil
+}
+
+func decodeBatchUpdate(c *gin.Context) (req batchUpdateRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+
+	for i := range req.Links {
+		req.Links[i].WhoID = req.WhoID
+		req.Links[i].LinkID = req.Links[i].ID
+
+		if domain := req.Links[i].Domain; domain != nil {
+			normalized := strings.ToLower(strings.TrimSpace(*domain))
+			req.Links[i].Domain = &normalized
+		}
+	}
+
+	err = checkBatch(req.WhoID, len(req.Links), viper.GetInt("LINKS_BATCH_MAX"))
+	return
+}
+
+func decodeBatchDelete(c *gin.Context) (req batchDeleteRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+	err = checkBatch(req.WhoID, len(req.IDs), viper.GetInt("LINKS_BATCH_MAX"))
+	return
+}
+
+// decodeExport get format, "csv" by default, and optional group from query.
+// Without group, only links from user are exported.
+func decodeExport(c *gin.Context) (req exportRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+	req.GroupID = c.Query("group_id")
+	req.Format = strings.ToLower(c.DefaultQuery("format", formatCSV))
+
+	if req.WhoID == "0" {
+		return req, e.ErrUnauthorized
+	}
+
+	if req.Format != formatCSV && req.Format != formatNDJSON {
+		return req, e.ErrLinkInvalidFormat
+	}
+	return
+}
+
+// decodeImport get file from multipart form field "file" or from raw body.
+func decodeImport(c *gin.Context) (req importRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+
+	// Refuse before reading the file.
+	if req.WhoID == "0" {
+		return req, e.ErrUnauthorized
+	}
+
+	req.Format = strings.ToLower(c.DefaultQuery("format", formatCSV))
+	req.Conflict = strings.ToLower(c.DefaultQuery("conflict", conflictSkip))
+	req.Domain = strings.ToLower(c.DefaultQuery("domain", c.Request.Host))
+
+	switch req.Format {
+	case formatCSV, formatNDJSON, formatBitly, formatYOURLS:
+	default:
+		return req, e.ErrLinkInvalidFormat
+	}
+
+	switch req.Conflict {
+	case conflictSkip, conflictOverwrite, conflictRename:
+	default:
+		return req, e.ErrLinkImportInvalidConflict
+	}
+
+	// Whole file is read in memory, so big files are refused before that.
+	maxSize := viper.GetInt64("LINKS_IMPORT_MAX_SIZE") << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	req.File = c.Request.Body
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			if tooLarge(err) {
+				return req, e.ErrLinkImportTooLarge
+			}
+			return req, e.ErrLinkImportInvalidFile
+		}
+
+		req.File, err = file.Open()
+		if err != nil {
+			return req, e.ErrLinkImportInvalidFile
+		}
+	}
+	return
+}
+
+// decodeQR get link ID from path, or keyword from public path like "/keyword.qr",
+// and image options from query.
+func decodeQR(c *gin.Context) (req qrRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+	req.Domain = strings.ToLower(c.Request.Host)
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get link id from path")
+	}
+
+	if keyword := c.Param("keyword"); keyword != "" {
+		req.Keyword = strings.TrimSuffix(keyword, qrSuffix)
+	}
+
+	req.Scheme = "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		req.Scheme = "https"
+	}
+
+	o := qr.DefaultOptions()
+	o.Format = strings.ToLower(c.DefaultQuery("format", o.Format))
+	o.Level = c.DefaultQuery("level", o.Level)
+	o.Foreground = c.DefaultQuery("fg", o.Foreground)
+	o.Background = c.DefaultQuery("bg", o.Background)
+
+	if o.Size, err = strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(o.Size))); err != nil {
+		return req, e.ErrQRInvalidSize
+	}
+
+	if o.Margin, err = strconv.Atoi(c.DefaultQuery("margin", strconv.Itoa(o.Margin))); err != nil {
+		return req, e.ErrQRInvalidMargin
+	}
+
+	if err = o.Validate(); err != nil {
+		return
+	}
+
+	req.Options = o
+	return req, nil
+}
+
+// decodeTags get tag IDs from body, without duplicates.
+func decodeTags(c *gin.Context) (req tagsRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get link id from path")
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	unique := map[string]bool{}
+	tagIDs := []string{}
+
+	for _, id := range req.TagIDs {
+		if !unique[id] {
+			unique[id] = true
+			tagIDs = append(tagIDs, id)
+		}
+	}
+
+	req.WhoID = v.(string)
+	req.TagIDs = tagIDs
+	return
+}
+
+func decodeTagRemove(c *gin.Context) (req tagRemoveRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get link and tag id from path")
+	}
+
+	req.WhoID = v.(string)
 	return
 }
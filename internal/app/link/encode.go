@@ -1,19 +1,66 @@
 package link
 
-import "github.com/wvoliveira/corgi/internal/pkg/model"
+import (
+	"time"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
 
 type findRedirectResponse struct {
 	URL string `json:"url"`
 }
 
+// findAllResponse is a page of links. Page, total and pages are zero when
+// request uses a cursor, because cursors don't count rows.
 type findAllResponse struct {
-	Links []model.Link `json:"links"`
-	Limit int          `json:"limit"`
-	Page  int          `json:"page"`
-	Sort  string       `json:"sort"`
-	Total int64        `json:"total"`
-	Pages int          `json:"pages"`
-	Err   error        `json:"error,omitempty"`
+	Links      []model.Link `json:"links"`
+	Limit      int          `json:"limit"`
+	Page       int          `json:"page"`
+	Sort       string       `json:"sort"`
+	Total      int64        `json:"total"`
+	Pages      int          `json:"pages"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Err        error        `json:"error,omitempty"`
+}
+
+// batchResult is the result of each item from a batch request, in same order.
+// Status is only used by imports, to show what was done with conflicts.
+type batchResult struct {
+	Index  int         `json:"index"`
+	ID     string      `json:"id,omitempty"`
+	Status string      `json:"status,omitempty"`
+	Link   *model.Link `json:"link,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []batchResult `json:"results"`
+}
+
+// exportLink is a link in exports, with its total of clicks.
+type exportLink struct {
+	ID          string     `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Domain      string     `json:"domain"`
+	Keyword     string     `json:"keyword"`
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   int        `json:"max_clicks"`
+	FallbackURL string     `json:"fallback_url"`
+	Protected   bool       `json:"protected"`
+	Clicks      int        `json:"clicks"`
+}
+
+type tagsResponse struct {
+	Tags []model.Tag `json:"tags"`
+}
+
+type historyResponse struct {
+	Revisions []model.LinkRevision `json:"revisions"`
 }
 
 type findByKeywordResponse struct {
@@ -29,3 +76,33 @@ func encodeFindByKeyword(link model.Link) (r findByKeywordResponse) {
 	r.URL = link.URL
 	return
 }
+
+func encodeBatch(results []batchResult) (r batchResponse) {
+	r.Total = len(results)
+	r.Results = results
+
+	for _, result := range results {
+		if result.Error != "" {
+			r.Failed++
+		}
+	}
+
+	r.Succeeded = r.Total - r.Failed
+	return
+}
+
+func encodeExport(link model.Link, clicks int) *exportLink {
+	return &exportLink{
+		ID:          link.ID,
+		CreatedAt:   link.CreatedAt,
+		Domain:      link.Domain,
+		Keyword:     link.Keyword,
+		URL:         link.URL,
+		Title:       link.Title,
+		ExpiresAt:   link.ExpiresAt,
+		MaxClicks:   link.MaxClicks,
+		FallbackURL: link.FallbackURL,
+		Protected:   link.Protected,
+		Clicks:      clicks,
+	}
+}
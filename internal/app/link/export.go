@@ -0,0 +1,174 @@
+package link
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatBitly  = "bitly"
+	formatYOURLS = "yourls"
+
+	// Flush response to client after these rows, so big exports don't stay in memory.
+	exportFlushRows = 500
+)
+
+// exportColumns is the header of CSV exports. Import read the same columns.
+var exportColumns = []string{"id", "created_at", "domain", "keyword", "url", "title",
+	"expires_at", "max_clicks", "fallback_url", "protected", "clicks"}
+
+// Export write active links from user, or from all members of a group, in CSV or NDJSON.
+// Group export has protected links only from user: a password protects the link from members too.
+// Rows are written while read from database.
+func (s service) Export(c *gin.Context, payload exportRequest, w io.Writer) (err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + linkColumns + `,
+		(SELECT COALESCE(SUM(total), 0) FROM links_clicks WHERE link_id = links.id)
+		FROM links WHERE user_id = $1 AND active = true ORDER BY id ASC`
+	args := []any{payload.WhoID}
+
+	if payload.GroupID != "" {
+		if err = s.checkGroupMember(c, payload.WhoID, payload.GroupID); err != nil {
+			return
+		}
+
+		query = "SELECT " + linkColumns + `,
+			(SELECT COALESCE(SUM(total), 0) FROM links_clicks WHERE link_id = links.id)
+			FROM links WHERE user_id IN (SELECT user_id FROM group_user WHERE group_id = $1) AND active = true
+			AND (user_id = $2 OR password = '')
+			ORDER BY id ASC`
+		args = []any{payload.GroupID, payload.WhoID}
+	}
+
+	log.Debug().Caller().Msg(query)
+
+	rows, err := s.db.QueryContext(c, query, args...)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+	defer rows.Close()
+
+	write := exportWriter(payload.Format, w)
+	total := 0
+
+	for rows.Next() {
+		link := model.Link{}
+		clicks := 0
+
+		if err = scanLink(rows, &link, &clicks); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+
+		if err = write(encodeExport(link, clicks)); err != nil {
+			return
+		}
+
+		total++
+		if total%exportFlushRows == 0 {
+			if err = write(nil); err != nil {
+				return
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return
+	}
+
+	log.Info().Caller().Msg(fmt.Sprintf("%d links exported in %s", total, payload.Format))
+	return write(nil)
+}
+
+// exportWriter return a function that write one link, or flush when link is nil.
+// CSV header is written before the first link.
+func exportWriter(format string, w io.Writer) func(*exportLink) error {
+	flush := func() {
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+	}
+
+	if format == formatNDJSON {
+		encoder := json.NewEncoder(w)
+
+		return func(link *exportLink) error {
+			if link == nil {
+				flush()
+				return nil
+			}
+			return encoder.Encode(link)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	header := false
+
+	return func(link *exportLink) error {
+		if !header {
+			header = true
+			if err := writer.Write(exportColumns); err != nil {
+				return err
+			}
+		}
+
+		if link == nil {
+			writer.Flush()
+			flush()
+			return writer.Error()
+		}
+
+		expiresAt := ""
+		if link.ExpiresAt != nil {
+			expiresAt = link.ExpiresAt.Format(time.RFC3339)
+		}
+
+		return writer.Write([]string{
+			link.ID,
+			link.CreatedAt.Format(time.RFC3339),
+			link.Domain,
+			link.Keyword,
+			link.URL,
+			link.Title,
+			expiresAt,
+			strconv.Itoa(link.MaxClicks),
+			link.FallbackURL,
+			strconv.FormatBool(link.Protected),
+			strconv.Itoa(link.Clicks),
+		})
+	}
+}
+
+// checkGroupMember return e.ErrGroupNotFound if user is not in group.
+func (s service) checkGroupMember(ctx context.Context, whoID, groupID string) (err error) {
+	log := logger.Logger(ctx)
+
+	member := 0
+	query := "SELECT COUNT(0) FROM group_user WHERE group_id = $1 AND user_id = $2"
+
+	err = s.db.QueryRowContext(ctx, query, groupID, whoID).Scan(&member)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if member == 0 {
+		return e.ErrGroupNotFound
+	}
+	return nil
+}
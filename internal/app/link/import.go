@@ -0,0 +1,388 @@
+package link
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
+	"github.com/spf13/viper"
+	"github.com/teris-io/shortid"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	// What to do when domain and keyword combination already exists.
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+
+	importCreated     = "created"
+	importSkipped     = "skipped"
+	importOverwritten = "overwritten"
+	importRenamed     = "renamed"
+
+	// Tries with suffix "-2", "-3"... before a random keyword.
+	maxRenameTries = 10
+)
+
+// importColumns map CSV header, in lower case, to link fields for each format.
+// Bitly and YOURLS exports have their own names.
+var importColumns = map[string]map[string]string{
+	formatCSV: {
+		"domain": "domain", "keyword": "keyword", "url": "url", "title": "title",
+		"expires_at": "expires_at", "max_clicks": "max_clicks", "fallback_url": "fallback_url",
+		"protected": "protected",
+	},
+	formatBitly: {
+		"long_url": "url", "long url": "url", "title": "title",
+		"link": "short_url", "bitlink": "short_url", "short_url": "short_url", "custom_bitlink": "short_url",
+	},
+	formatYOURLS: {
+		"keyword": "keyword", "url": "url", "title": "title",
+	},
+}
+
+// YOURLS exports can be without header: keyword, url, title, timestamp, ip, clicks.
+var yourlsPositional = []string{"keyword", "url", "title"}
+
+// importRow is a link read from import file, or why it could not be read.
+type importRow struct {
+	Link addRequest
+	Err  error
+}
+
+// Import create links from a file in one transaction. Conflicts are solved by payload.Conflict.
+// Rows with errors are reported in results and the others are imported anyway.
+func (s service) Import(c *gin.Context, payload importRequest) (results []batchResult, err error) {
+	log := logger.Logger(c)
+
+	rows, err := parseImport(payload.Format, payload.File)
+	if err != nil {
+		log.Warn().Caller().Msg(err.Error())
+
+		if tooLarge(err) {
+			return results, e.ErrLinkImportTooLarge
+		}
+		return results, e.ErrLinkImportInvalidFile
+	}
+
+	if err = checkBatch(payload.WhoID, len(rows), viper.GetInt("LINKS_IMPORT_MAX")); err != nil {
+		return
+	}
+
+	results = make([]batchResult, len(rows))
+
+	err = s.batch(c, len(rows), func(tx *sql.Tx, i int) error {
+		if rows[i].Err != nil {
+			return rows[i].Err
+		}
+
+		item := rows[i].Link
+		item.WhoID = payload.WhoID
+
+		if item.Domain == "" {
+			item.Domain = payload.Domain
+		}
+
+		newLink, err := s.newLink(c, item)
+		if err != nil {
+			return err
+		}
+
+		link, err := insertLink(c, tx, newLink)
+		results[i].Status = importCreated
+
+		if errors.Is(err, e.ErrLinkAlreadyExists) {
+			switch payload.Conflict {
+			case conflictOverwrite:
+				link, err = overwriteLink(c, tx, newLink)
+				results[i].Status = importOverwritten
+
+			case conflictRename:
+				link, err = renameLink(c, tx, newLink)
+				results[i].Status = importRenamed
+
+			default:
+				results[i].Status = importSkipped
+				return nil
+			}
+		}
+
+		if err != nil {
+			results[i].Status = ""
+			return err
+		}
+
+		results[i].ID = link.ID
+		results[i].Link = &link
+		return nil
+	}, results)
+
+	if err != nil {
+		return
+	}
+
+	// Overwritten links are in cache with old values, new ones may be cached as not found.
+	s.uncache(c, batchLinks(results)...)
+	return results, nil
+}
+
+// overwriteLink replace all fields of an existent link, like targets, variants and password, by the imported ones.
+// Only links from same user can be replaced. Changes are saved as revisions, like in Update.
+func overwriteLink(ctx context.Context, tx *sql.Tx, newLink model.Link) (link model.Link, err error) {
+	log := logger.Logger(ctx)
+
+	old := model.Link{}
+	query := "SELECT " + linkColumns + " FROM links WHERE domain = $1 AND keyword = $2 AND user_id = $3 FOR UPDATE"
+
+	err = scanLink(tx.QueryRowContext(ctx, query, newLink.Domain, newLink.Keyword, newLink.UserID), &old)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return link, e.ErrLinkAlreadyExists
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
+
+	if old.Targets, err = linkTargets(ctx, tx, old.ID); err != nil {
+		return
+	}
+
+	if old.Variants, err = linkVariants(ctx, tx, old.ID); err != nil {
+		return
+	}
+
+	query = `UPDATE links SET url = $1, title = $2, expires_at = $3, max_clicks = $4, fallback_url = $5,
+		password = $6, redirect_type = $7, forward_query = $8, forward_path = $9, utm_source = $10,
+		utm_medium = $11, utm_campaign = $12, utm_term = $13, utm_content = $14, sticky_variant = $15,
+		starts_at = $16, ends_at = $17, schedule = NULLIF($18, '')::JSONB, unavailable_url = $19,
+		folder_id = NULLIF($22, ''), active = true, deleted_at = NULL, updated_at = $20
+		WHERE id = $21
+		RETURNING ` + linkColumns
+
+	row := tx.QueryRowContext(ctx, query, newLink.URL, newLink.Title, newLink.ExpiresAt, newLink.MaxClicks,
+		newLink.FallbackURL, newLink.Password, newLink.RedirectType, newLink.ForwardQuery, newLink.ForwardPath,
+		newLink.Source, newLink.Medium, newLink.Campaign, newLink.Term, newLink.Content, newLink.StickyVariant,
+		newLink.StartsAt, newLink.EndsAt, scheduleValue(newLink.Schedule), newLink.UnavailableURL, time.Now(), old.ID,
+		newLink.FolderID)
+
+	if err = scanLink(row, &link); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
+
+	if err = setTargets(ctx, tx, link.ID, newLink.Targets); err != nil {
+		return
+	}
+	link.Targets = newLink.Targets
+
+	if err = setVariants(ctx, tx, link.ID, newLink.Variants); err != nil {
+		return
+	}
+	link.Variants = newLink.Variants
+
+	revisions := diffLink(old, link)
+	err = insertRevisions(ctx, tx, link.ID, newLink.UserID, revisions)
+	return
+}
+
+// renameLink insert link with a keyword like "keyword-2". After some tries, a random keyword is used.
+func renameLink(ctx context.Context, tx *sql.Tx, newLink model.Link) (link model.Link, err error) {
+	keyword := newLink.Keyword
+
+	for i := 2; i <= maxRenameTries+1; i++ {
+		newLink.ID = ulid.Make().String()
+		newLink.Keyword = fmt.Sprintf("%s-%d", keyword, i)
+
+		if i > maxRenameTries {
+			sid, _ := shortid.New(1, shortid.DefaultABC, 2342)
+			newLink.Keyword, _ = sid.Generate()
+		}
+
+		link, err = insertLink(ctx, tx, newLink)
+		if !errors.Is(err, e.ErrLinkAlreadyExists) {
+			return
+		}
+	}
+	return
+}
+
+// tooLarge is true if err is from a body bigger than LINKS_IMPORT_MAX_SIZE.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// parseImport read links from CSV, NDJSON, Bitly or YOURLS files.
+func parseImport(format string, r io.Reader) (rows []importRow, err error) {
+	if format == formatNDJSON {
+		return parseNDJSON(r)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+
+	if len(records) == 0 {
+		return
+	}
+
+	columns := map[int]string{}
+	aliases := importColumns[format]
+
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := aliases[name]; ok {
+			columns[i] = field
+		}
+	}
+
+	if hasColumn(columns, "url") {
+		records = records[1:]
+	} else if format == formatYOURLS {
+		for i, field := range yourlsPositional {
+			columns[i] = field
+		}
+	} else {
+		return rows, errors.New("header without an URL column")
+	}
+
+	rows = make([]importRow, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, parseRecord(columns, record))
+	}
+	return
+}
+
+// parseRecord convert a CSV record to a link using columns from header.
+func parseRecord(columns map[int]string, record []string) (row importRow) {
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+
+		switch columns[i] {
+		case "domain":
+			row.Link.Domain = strings.ToLower(value)
+		case "keyword":
+			row.Link.Keyword = value
+		case "url":
+			row.Link.URL = value
+		case "title":
+			row.Link.Title = value
+		case "fallback_url":
+			row.Link.FallbackURL = value
+
+		case "protected":
+			// Password hashes are not exported, so protected links can't be imported.
+			if protected, _ := strconv.ParseBool(value); protected {
+				row.Err = e.ErrLinkImportProtected
+				return
+			}
+
+		case "short_url":
+			// Domain from another shortener is not ours, so only keyword is used.
+			if u, err := url.Parse(withScheme(value)); err == nil {
+				row.Link.Keyword = strings.Trim(u.Path, "/")
+			}
+
+		case "max_clicks":
+			if value == "" {
+				continue
+			}
+
+			max, err := strconv.Atoi(value)
+			if err != nil {
+				row.Err = e.ErrLinkInvalidMaxClicks
+				return
+			}
+			row.Link.MaxClicks = max
+
+		case "expires_at":
+			if value == "" {
+				continue
+			}
+
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				row.Err = e.ErrLinkInvalidExpiration
+				return
+			}
+			row.Link.ExpiresAt = &t
+		}
+	}
+	return
+}
+
+// parseNDJSON read one link per line, in the same layout from exports.
+func parseNDJSON(r io.Reader) (rows []importRow, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row := importRow{}
+		link := exportLink{}
+
+		if err := json.Unmarshal([]byte(line), &link); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		if link.Protected {
+			row.Err = e.ErrLinkImportProtected
+		}
+
+		row.Link = addRequest{
+			Domain:      strings.ToLower(link.Domain),
+			Keyword:     link.Keyword,
+			URL:         link.URL,
+			Title:       link.Title,
+			ExpiresAt:   link.ExpiresAt,
+			MaxClicks:   link.MaxClicks,
+			FallbackURL: link.FallbackURL,
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+func hasColumn(columns map[int]string, field string) bool {
+	for _, f := range columns {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// withScheme add "https://" to links like "bit.ly/abc", so they can be parsed.
+func withScheme(link string) string {
+	if strings.Contains(link, "://") {
+		return link
+	}
+	return "https://" + link
+}
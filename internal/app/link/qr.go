@@ -0,0 +1,64 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+	"github.com/wvoliveira/corgi/internal/pkg/qr"
+)
+
+const (
+	// Public QR code path is the short link with this suffix. Ex.: /keyword.qr
+	qrSuffix = ".qr"
+
+	keyCacheShortLinkQR = "cache:link_short:%s:%s:qr:%s:%s" // Ex.: cache:link_short:domain:keyword:qr:scheme:options
+	qrCacheExpiration   = 24 * time.Hour
+)
+
+// QRCode render short URL from a link as PNG or SVG.
+// Link is found by ID from user or, in public path, by domain and keyword.
+func (s service) QRCode(c *gin.Context, payload qrRequest) (image []byte, err error) {
+	log := logger.Logger(c)
+	link := model.Link{}
+
+	if payload.Keyword != "" {
+		link, err = s.findByKeyword(c, payload.Domain, payload.Keyword)
+	} else {
+		link, err = s.FindByID(c, findByIDRequest{WhoID: payload.WhoID, LinkID: payload.LinkID})
+	}
+
+	if err != nil {
+		return
+	}
+
+	key := fmt.Sprintf(keyCacheShortLinkQR, link.Domain, link.Keyword, payload.Scheme, payload.Options.String())
+
+	image, err = s.cache.Get(c, key).Bytes()
+	if err == nil {
+		return
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	shortURL := fmt.Sprintf("%s://%s/%s", payload.Scheme, link.Domain, link.Keyword)
+
+	image, err = qr.Encode(shortURL, payload.Options)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return image, e.ErrInternalServerError
+	}
+
+	// Keep going on error from cache.
+	if err := s.cache.Set(c, key, image, qrCacheExpiration).Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+	return image, nil
+}
@@ -0,0 +1,169 @@
+package link
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const redirectMeta = "meta"
+
+// redirectStatus map redirect types to its HTTP status. Meta is a page, so it's 200.
+var redirectStatus = map[string]int{
+	"301":        http.StatusMovedPermanently,
+	"302":        http.StatusFound,
+	"307":        http.StatusTemporaryRedirect,
+	"308":        http.StatusPermanentRedirect,
+	redirectMeta: http.StatusOK,
+}
+
+// metaPage show destination before going there, for links with "meta" redirect type.
+var metaPage = template.Must(template.New("meta").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<meta http-equiv="refresh" content="3;url={{.URL}}">
+	<title>Redirecting</title>
+</head>
+<body>
+	<h1>{{if .Title}}{{.Title}}{{else}}You are being redirected{{end}}</h1>
+	<p>Going to <a href="{{.URL}}">{{.URL}}</a> in a few seconds.</p>
+</body>
+</html>
+`))
+
+// temporaryRedirect is the redirect type used instead of a permanent one by links that are not cacheable.
+var temporaryRedirect = map[string]string{
+	"301": "302",
+	"308": "307",
+}
+
+// redirectType of a link, or the system default when link has none.
+// Permanent types (301 and 308) only apply to static links, browsers would keep the others forever.
+func redirectType(link model.Link) string {
+	t := link.RedirectType
+
+	if t == "" {
+		t = viper.GetString("LINKS_REDIRECT_TYPE")
+		if checkRedirectType(t) != nil || t == "" {
+			t = "301"
+		}
+	}
+
+	if temporary, ok := temporaryRedirect[t]; ok && (!cacheable(link) || link.VariantID != "") {
+		return temporary
+	}
+	return t
+}
+
+// cacheable check if browsers can keep the redirect of a link.
+// Links that expire, run out of clicks, change with time or by visitor must be requested at each visit.
+func cacheable(link model.Link) bool {
+	return link.ExpiresAt == nil && link.MaxClicks == 0 && !scheduled(link) && len(link.Targets) == 0
+}
+
+// encodeFallback send visitor of an expired link to its fallback URL.
+// Expiration can be changed later, so browsers must not keep it.
+func encodeFallback(c *gin.Context, link model.Link) {
+	c.Header("Cache-Control", "no-store")
+
+	link.URL = link.FallbackURL
+	encodeRedirectLink(c, link)
+}
+
+// destinationURL is where a visitor goes: link destination with its UTM parameters and,
+// when link allows it, path and query string from request.
+// Query params already in destination are kept, UTM and request only add new ones, in this order.
+func destinationURL(link model.Link, reqPath, query string) string {
+	if !link.ForwardPath {
+		reqPath = ""
+	}
+
+	if !link.ForwardQuery {
+		query = ""
+	}
+
+	utm := link.UTM.Values()
+
+	// Cleaned alone, so "../" can't go above destination path.
+	reqPath = strings.Trim(path.Clean("/"+reqPath), "/")
+	if reqPath == "" && query == "" && len(utm) == 0 {
+		return link.URL
+	}
+
+	u, err := url.Parse(link.URL)
+	if err != nil {
+		return link.URL
+	}
+
+	if reqPath != "" {
+		u = u.JoinPath(reqPath)
+	}
+
+	addMissingQuery(u, utm)
+
+	incoming, err := url.ParseQuery(query)
+	if err != nil {
+		return u.String()
+	}
+
+	addMissingQuery(u, incoming)
+	return u.String()
+}
+
+// addMissingQuery append to URL only params with a key that it doesn't have yet.
+func addMissingQuery(u *url.URL, values url.Values) {
+	current := u.Query()
+	extra := url.Values{}
+
+	for key, v := range values {
+		if _, ok := current[key]; !ok {
+			extra[key] = v
+		}
+	}
+
+	if len(extra) == 0 {
+		return
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += extra.Encode()
+}
+
+// encodeRedirectLink send visitor to link destination with the redirect type of link.
+func encodeRedirectLink(c *gin.Context, link model.Link) {
+	log := logger.Logger(c)
+	t := redirectType(link)
+
+	// Only web pages are shown in meta refresh, anything else is a normal redirect.
+	if t == redirectMeta {
+		u, err := url.Parse(link.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			t = "302"
+		}
+	}
+
+	if t != redirectMeta {
+		c.Redirect(redirectStatus[t], link.URL)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := metaPage.Execute(c.Writer, link); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+}
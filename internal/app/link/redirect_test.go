@@ -0,0 +1,114 @@
+package link
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+func TestDestinationURL(t *testing.T) {
+	forward := model.Link{URL: "https://example.com/docs?lang=en", ForwardPath: true, ForwardQuery: true}
+
+	tests := []struct {
+		name  string
+		link  model.Link
+		path  string
+		query string
+		want  string
+	}{
+		{"nothing to add", model.Link{URL: "https://example.com/a?b=c"}, "", "", "https://example.com/a?b=c"},
+		{"forward disabled", model.Link{URL: "https://example.com"}, "x/y", "q=1", "https://example.com"},
+		{"path", forward, "guide/intro", "", "https://example.com/docs/guide/intro?lang=en"},
+		{"query", forward, "", "q=1", "https://example.com/docs?lang=en&q=1"},
+		{"path and query", forward, "guide", "q=1", "https://example.com/docs/guide?lang=en&q=1"},
+		{"destination query is kept", forward, "", "lang=pt&q=1", "https://example.com/docs?lang=en&q=1"},
+		{"path can't go up", forward, "../../etc/passwd", "", "https://example.com/docs/etc/passwd?lang=en"},
+		{"only path forwarded", model.Link{URL: "https://example.com", ForwardPath: true}, "a", "q=1", "https://example.com/a"},
+		{"only query forwarded", model.Link{URL: "https://example.com", ForwardQuery: true}, "a", "q=1", "https://example.com?q=1"},
+		{"invalid query is ignored", forward, "", "q=%zz", "https://example.com/docs?lang=en"},
+		{
+			name:  "utm before request",
+			link:  model.Link{URL: "https://example.com", ForwardQuery: true, UTM: model.UTM{Source: "news", Medium: "email"}},
+			query: "utm_source=other&q=1",
+			want:  "https://example.com?utm_medium=email&utm_source=news&q=1",
+		},
+		{
+			name: "utm doesn't replace destination",
+			link: model.Link{URL: "https://example.com?utm_source=site", UTM: model.UTM{Source: "news"}},
+			want: "https://example.com?utm_source=site",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := destinationURL(tt.link, tt.path, tt.query); got != tt.want {
+				t.Errorf("destinationURL() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	now := time.Now()
+	schedule := &model.LinkSchedule{Timezone: "UTC", Days: []string{"mon"}, From: "09:00", To: "18:00"}
+
+	tests := []struct {
+		name string
+		link model.Link
+		want bool
+	}{
+		{"static", model.Link{URL: "https://example.com"}, true},
+		{"with variants", model.Link{Variants: []model.LinkVariant{{ID: "a"}}}, true},
+		{"expires at", model.Link{ExpiresAt: &now}, false},
+		{"max clicks", model.Link{MaxClicks: 10}, false},
+		{"starts at", model.Link{StartsAt: &now}, false},
+		{"ends at", model.Link{EndsAt: &now}, false},
+		{"schedule", model.Link{Schedule: schedule}, false},
+		{"targets", model.Link{Targets: []model.LinkTarget{{Device: "mobile"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheable(tt.link); got != tt.want {
+				t.Errorf("cacheable() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectType(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		config string
+		link   model.Link
+		want   string
+	}{
+		{"default", "", model.Link{}, "301"},
+		{"invalid config", "999", model.Link{}, "301"},
+		{"from config", "302", model.Link{}, "302"},
+		{"from link", "302", model.Link{RedirectType: "308"}, "308"},
+		{"meta", "", model.Link{RedirectType: "meta"}, "meta"},
+		{"temporary is kept", "", model.Link{RedirectType: "307", MaxClicks: 1}, "307"},
+		{"default with expiration", "", model.Link{ExpiresAt: &now}, "302"},
+		{"301 with max clicks", "", model.Link{RedirectType: "301", MaxClicks: 1}, "302"},
+		{"308 with targets", "", model.Link{RedirectType: "308", Targets: []model.LinkTarget{{OS: "ios"}}}, "307"},
+		{"config 308 with schedule", "308", model.Link{StartsAt: &now}, "307"},
+		{"chosen variant", "", model.Link{VariantID: "a"}, "302"},
+		{"meta with variant", "", model.Link{RedirectType: "meta", VariantID: "a"}, "meta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("LINKS_REDIRECT_TYPE", tt.config)
+			defer viper.Set("LINKS_REDIRECT_TYPE", nil)
+
+			if got := redirectType(tt.link); got != tt.want {
+				t.Errorf("redirectType() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// History get all changes from a link, newest first.
+func (s service) History(c *gin.Context, payload findByIDRequest) (revisions []model.LinkRevision, err error) {
+	log := logger.Logger(c)
+
+	linkID := ""
+	err = s.db.QueryRowContext(c, "SELECT id FROM links WHERE id = $1 AND user_id = $2", payload.LinkID, payload.WhoID).
+		Scan(&linkID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return revisions, e.ErrLinkNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return revisions, e.ErrInternalServerError
+	}
+
+	query := `SELECT r.id, r.created_at, r.link_id, r.user_id, u.name, r.field, r.old_value, r.new_value
+		FROM link_revisions r
+		INNER JOIN users u ON u.id = r.user_id
+		WHERE r.link_id = $1
+		ORDER BY r.id DESC`
+	log.Debug().Caller().Msg(query)
+
+	rows, err := s.db.QueryContext(c, query, linkID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return revisions, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	revisions = []model.LinkRevision{}
+
+	for rows.Next() {
+		r := model.LinkRevision{}
+
+		err = rows.Scan(&r.ID, &r.CreatedAt, &r.LinkID, &r.UserID, &r.UserName, &r.Field, &r.OldValue, &r.NewValue)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return revisions, e.ErrInternalServerError
+		}
+
+		revisions = append(revisions, r)
+	}
+	return
+}
+
+// diffLink list fields with a different value in "changed", one revision for each.
+func diffLink(old, changed model.Link) (revisions []model.LinkRevision) {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", old.Title, changed.Title},
+		{"url", old.URL, changed.URL},
+		{"keyword", old.Keyword, changed.Keyword},
+		{"domain", old.Domain, changed.Domain},
+		{"active", old.Active, changed.Active},
+		{"redirect_type", old.RedirectType, changed.RedirectType},
+		{"forward_query", strconv.FormatBool(old.ForwardQuery), strconv.FormatBool(changed.ForwardQuery)},
+		{"forward_path", strconv.FormatBool(old.ForwardPath), strconv.FormatBool(changed.ForwardPath)},
+		{"targets", targetsValue(old.Targets), targetsValue(changed.Targets)},
+		{"variants", variantsValue(old.Variants), variantsValue(changed.Variants)},
+		{"sticky_variant", strconv.FormatBool(old.StickyVariant), strconv.FormatBool(changed.StickyVariant)},
+		{"starts_at", timeValue(old.StartsAt), timeValue(changed.StartsAt)},
+		{"ends_at", timeValue(old.EndsAt), timeValue(changed.EndsAt)},
+		{"schedule", scheduleValue(old.Schedule), scheduleValue(changed.Schedule)},
+		{"unavailable_url", old.UnavailableURL, changed.UnavailableURL},
+		{"folder_id", old.FolderID, changed.FolderID},
+	}
+
+	for _, field := range fields {
+		if field.old != field.new {
+			revisions = append(revisions, model.LinkRevision{Field: field.name, OldValue: field.old, NewValue: field.new})
+		}
+	}
+	return
+}
+
+// insertRevisions save changes made by a user in a link.
+func insertRevisions(ctx context.Context, q queryer, linkID, whoID string, revisions []model.LinkRevision) (err error) {
+	log := logger.Logger(ctx)
+
+	query := `INSERT INTO link_revisions(id, link_id, user_id, field, old_value, new_value)
+		VALUES($1, $2, $3, $4, $5, $6)`
+
+	for _, r := range revisions {
+		_, err = q.ExecContext(ctx, query, ulid.Make().String(), linkID, whoID, r.Field, r.OldValue, r.NewValue)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+	}
+	return
+}
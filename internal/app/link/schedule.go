@@ -0,0 +1,311 @@
+package link
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// linkCacheTTL is how long a link stays in cache, if its window doesn't change before.
+const linkCacheTTL = 10 * time.Minute
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// defaultUnavailablePage is shown by links outside of their window, without an unavailable URL.
+var defaultUnavailablePage = template.Must(template.New("unavailable").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>Not available</title>
+</head>
+<body>
+	<h1>{{if .Title}}{{.Title}} is{{else}}This link is{{end}} not available now</h1>
+	{{if .Next}}<p>Come back at {{.Next.Format "2006-01-02 15:04 MST"}}.</p>{{end}}
+</body>
+</html>
+`))
+
+var (
+	unavailablePageOnce sync.Once
+	unavailablePageTmpl *template.Template
+)
+
+// unavailablePage is the template from config, or the built-in one.
+// Config doesn't change while running, so the file is read only once.
+func unavailablePage() *template.Template {
+	unavailablePageOnce.Do(func() {
+		unavailablePageTmpl = defaultUnavailablePage
+
+		file := viper.GetString("LINKS_UNAVAILABLE_PAGE")
+		if file == "" {
+			return
+		}
+
+		tmpl, err := template.ParseFiles(file)
+		if err != nil {
+			log.Error().Caller().Msg(fmt.Sprintf("impossible to load unavailable page '%s': %s", file, err.Error()))
+			return
+		}
+		unavailablePageTmpl = tmpl
+	})
+	return unavailablePageTmpl
+}
+
+// available check if link works at "now", and when it changes. Zero "next" is never.
+func available(link model.Link, now time.Time) (ok bool, next time.Time) {
+	if link.EndsAt != nil && !now.Before(*link.EndsAt) {
+		return false, time.Time{}
+	}
+
+	if link.StartsAt != nil && now.Before(*link.StartsAt) {
+		return false, *link.StartsAt
+	}
+
+	ok = true
+	if link.Schedule != nil {
+		ok, next = scheduleAt(*link.Schedule, now)
+	}
+
+	if link.EndsAt == nil || (!next.IsZero() && next.Before(*link.EndsAt)) {
+		return
+	}
+
+	// Closed until after the end, so it never opens again.
+	if !ok {
+		return false, time.Time{}
+	}
+	return true, *link.EndsAt
+}
+
+// scheduleAt check if schedule is open at "now", and when it opens or closes.
+func scheduleAt(s model.LinkSchedule, now time.Time) (open bool, next time.Time) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	from, _ := clockMinutes(s.From)
+	to, _ := clockMinutes(s.To)
+	t := now.In(loc)
+
+	for i := 0; i <= 7; i++ {
+		day := t.AddDate(0, 0, i)
+		if !hasWeekday(s.Days, day.Weekday()) {
+			continue
+		}
+
+		y, m, d := day.Date()
+		start := time.Date(y, m, d, from/60, from%60, 0, 0, loc)
+		end := time.Date(y, m, d, to/60, to%60, 0, 0, loc)
+
+		if now.Before(start) {
+			return false, start
+		}
+
+		if now.Before(end) {
+			return true, end
+		}
+	}
+	return false, time.Time{}
+}
+
+func hasWeekday(days []string, weekday time.Weekday) bool {
+	for _, day := range days {
+		if weekdays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes convert "HH:MM" in minutes from start of day. "24:00" is the end of day.
+func clockMinutes(clock string) (minutes int, ok bool) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// scheduled check if link has any window, so its redirect changes with time.
+func scheduled(link model.Link) bool {
+	return link.StartsAt != nil || link.EndsAt != nil || link.Schedule != nil
+}
+
+// cacheTTL of a link. Entries expire at window boundaries too,
+// so nothing read from cache outlives a change in availability.
+func cacheTTL(link model.Link, now time.Time) time.Duration {
+	ttl := linkCacheTTL
+
+	if _, next := available(link, now); !next.IsZero() && next.Sub(now) < ttl {
+		ttl = next.Sub(now)
+	}
+
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+// normalizeSchedule lower days and keep times in UTC, like other timestamps in database.
+func normalizeSchedule(startsAt, endsAt *time.Time, schedule *model.LinkSchedule) (*time.Time, *time.Time, *model.LinkSchedule) {
+	if startsAt != nil {
+		t := startsAt.UTC()
+		startsAt = &t
+	}
+
+	if endsAt != nil {
+		t := endsAt.UTC()
+		endsAt = &t
+	}
+
+	if schedule != nil {
+		s := model.LinkSchedule{
+			Timezone: strings.TrimSpace(schedule.Timezone),
+			From:     strings.TrimSpace(schedule.From),
+			To:       strings.TrimSpace(schedule.To),
+		}
+
+		for _, day := range schedule.Days {
+			s.Days = append(s.Days, strings.ToLower(strings.TrimSpace(day)))
+		}
+		schedule = &s
+	}
+	return startsAt, endsAt, schedule
+}
+
+// checkSchedule validate window, weekly schedule and unavailable URL of a link.
+func checkSchedule(startsAt, endsAt *time.Time, schedule *model.LinkSchedule, unavailableURL string) (err error) {
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return e.ErrLinkInvalidSchedule
+	}
+
+	if schedule != nil {
+		if _, err = time.LoadLocation(schedule.Timezone); err != nil {
+			return e.ErrLinkInvalidSchedule
+		}
+
+		if len(schedule.Days) == 0 {
+			return e.ErrLinkInvalidSchedule
+		}
+
+		for _, day := range schedule.Days {
+			if _, ok := weekdays[day]; !ok {
+				return e.ErrLinkInvalidSchedule
+			}
+		}
+
+		from, ok := clockMinutes(schedule.From)
+		if !ok {
+			return e.ErrLinkInvalidSchedule
+		}
+
+		to, ok := clockMinutes(schedule.To)
+		if !ok || to <= from {
+			return e.ErrLinkInvalidSchedule
+		}
+	}
+
+	if unavailableURL == "" {
+		return nil
+	}
+
+	if err = validation.Validate(unavailableURL, is.URL); err != nil {
+		return e.ErrLinkInvalidSchedule
+	}
+	return nil
+}
+
+// scheduleValue is how schedule is saved in database and revisions. Empty is no schedule.
+func scheduleValue(schedule *model.LinkSchedule) string {
+	if schedule == nil {
+		return ""
+	}
+
+	b, _ := json.Marshal(schedule)
+	return string(b)
+}
+
+// timeValue is how optional times are saved in revisions.
+func timeValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// encodeUnavailable send visitor to unavailable URL of link, or show a page until its window opens.
+func encodeUnavailable(c *gin.Context, link model.Link) {
+	log := logger.Logger(c)
+	_, next := available(link, time.Now())
+
+	c.Header("Cache-Control", "no-store")
+
+	if link.UnavailableURL != "" {
+		c.Redirect(http.StatusFound, link.UnavailableURL)
+		return
+	}
+
+	data := struct {
+		Title string
+		Next  *time.Time
+	}{Title: link.Title}
+
+	if !next.IsZero() {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(next).Seconds()))))
+
+		if link.Schedule != nil {
+			if loc, err := time.LoadLocation(link.Schedule.Timezone); err == nil {
+				next = next.In(loc)
+			}
+		}
+		data.Next = &next
+	}
+
+	if !wantsHTML(c) {
+		e.EncodeError(c, e.ErrLinkUnavailable)
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusServiceUnavailable)
+
+	if err := unavailablePage().Execute(c.Writer, data); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+}
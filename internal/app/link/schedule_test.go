@@ -0,0 +1,114 @@
+package link
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// Monday, 2024-01-01, in UTC.
+func monday(hour, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleAt(t *testing.T) {
+	weekdays := model.LinkSchedule{Timezone: "UTC", Days: []string{"mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "18:00"}
+	saoPaulo := weekdays
+	saoPaulo.Timezone = "America/Sao_Paulo"
+	invalid := weekdays
+	invalid.Timezone = "Nowhere/Nothing"
+	allDay := model.LinkSchedule{Timezone: "UTC", Days: []string{"mon"}, From: "00:00", To: "24:00"}
+
+	tests := []struct {
+		name     string
+		schedule model.LinkSchedule
+		now      time.Time
+		open     bool
+		next     time.Time
+	}{
+		{"before opening", weekdays, monday(8, 0), false, monday(9, 0)},
+		{"at opening", weekdays, monday(9, 0), true, monday(18, 0)},
+		{"open", weekdays, monday(10, 30), true, monday(18, 0)},
+		{"at closing", weekdays, monday(18, 0), false, monday(9, 0).AddDate(0, 0, 1)},
+		{"friday night opens monday", weekdays, monday(19, 0).AddDate(0, 0, 4), false, monday(9, 0).AddDate(0, 0, 7)},
+		{"weekend", weekdays, monday(12, 0).AddDate(0, 0, 5), false, monday(9, 0).AddDate(0, 0, 7)},
+		{"timezone before opening", saoPaulo, monday(11, 0), false, monday(12, 0)},
+		{"timezone open", saoPaulo, monday(13, 0), true, monday(21, 0)},
+		{"invalid timezone is UTC", invalid, monday(10, 0), true, monday(18, 0)},
+		{"end of day", allDay, monday(23, 59), true, monday(0, 0).AddDate(0, 0, 1)},
+		{"no days", model.LinkSchedule{Timezone: "UTC", From: "09:00", To: "18:00"}, monday(10, 0), false, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, next := scheduleAt(tt.schedule, tt.now)
+			if open != tt.open || !next.Equal(tt.next) {
+				t.Errorf("scheduleAt() = %v, %v; want %v, %v", open, next, tt.open, tt.next)
+			}
+		})
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	start := monday(9, 0)
+	end := monday(12, 0)
+	schedule := &model.LinkSchedule{Timezone: "UTC", Days: []string{"mon"}, From: "10:00", To: "11:00"}
+	lateSchedule := &model.LinkSchedule{Timezone: "UTC", Days: []string{"mon"}, From: "13:00", To: "14:00"}
+
+	tests := []struct {
+		name string
+		link model.Link
+		now  time.Time
+		ok   bool
+		next time.Time
+	}{
+		{"no window", model.Link{}, monday(8, 0), true, time.Time{}},
+		{"before start", model.Link{StartsAt: &start}, monday(8, 0), false, start},
+		{"after start", model.Link{StartsAt: &start}, monday(10, 0), true, time.Time{}},
+		{"before end", model.Link{EndsAt: &end}, monday(10, 0), true, end},
+		{"at end", model.Link{EndsAt: &end}, end, false, time.Time{}},
+		{"inside window", model.Link{StartsAt: &start, EndsAt: &end}, monday(10, 0), true, end},
+		{"schedule closes before end", model.Link{EndsAt: &end, Schedule: schedule}, monday(10, 30), true, monday(11, 0)},
+		{"schedule opens before end", model.Link{EndsAt: &end, Schedule: schedule}, monday(9, 30), false, monday(10, 0)},
+		{"schedule opens after end", model.Link{EndsAt: &end, Schedule: lateSchedule}, monday(10, 0), false, time.Time{}},
+		{"schedule without end", model.Link{Schedule: schedule}, monday(11, 30), false, monday(10, 0).AddDate(0, 0, 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, next := available(tt.link, tt.now)
+			if ok != tt.ok || !next.Equal(tt.next) {
+				t.Errorf("available() = %v, %v; want %v, %v", ok, next, tt.ok, tt.next)
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := monday(10, 0)
+	soon := now.Add(2 * time.Minute)
+	later := now.Add(time.Hour)
+	now100ms := now.Add(100 * time.Millisecond)
+
+	tests := []struct {
+		name string
+		link model.Link
+		want time.Duration
+	}{
+		{"no window", model.Link{}, linkCacheTTL},
+		{"ends soon", model.Link{EndsAt: &soon}, 2 * time.Minute},
+		{"ends later", model.Link{EndsAt: &later}, linkCacheTTL},
+		{"starts soon", model.Link{StartsAt: &soon}, 2 * time.Minute},
+		{"at least a second", model.Link{EndsAt: &now100ms}, time.Second},
+		{"already ended", model.Link{EndsAt: &now}, linkCacheTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheTTL(tt.link, now); got != tt.want {
+				t.Errorf("cacheTTL() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,45 +3,90 @@ package link
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/wvoliveira/corgi/internal/pkg/common"
+	"github.com/wvoliveira/corgi/internal/pkg/cursor"
+	"io"
 	"math"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/teris-io/shortid"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/location"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
 	"github.com/wvoliveira/corgi/internal/pkg/model"
 )
 
 const (
 	keyCacheShortLink                   = "cache:link_short:%s:%s"                      // Ex.: cache:link_short:domain:keyword
-	keyCacheShortLinkMetricCounterTotal = "cache:link_short:%s:%s:metric:counter_total" // Cache value for sum of counter metric.
+	keyCacheShortLinkMetricCounterHours = "cache:link_short:%s:%s:metric:counter_hours" // Cache value for counters by hour, in JSON.
+
+	cacheNotFound    = "not_found" // Cache value of keywords without link. Removed when a link is created.
+	cacheNotFoundTTL = time.Minute
 
 	// These values stay inside hash value.
 	keyMetricShortLink = "metric:link_short:%s:%s" // Ex.: metric:link_short:domain:keyword
-	keyMetricCounter   = "counter:%s"              // Ex.: counter:yyyy-mm-dd-hh-MM
-	keyLatestSync      = "latest:sync:%s"          // Ex.: latest:sync:<timestamp>
-	keyLatestCheck     = "latest:check:%s"         // Ex.: latest:check:<timestamp>
+	keyMetricCounter   = "counter:%s"              // Ex.: counter:yyyy-mm-dd-hh
+	keyLatestSync      = "latest:sync:%s"          // Ex.: latest:sync:yyyy-mm-dd-hh = counter value persisted in database.
+	keyLatestCheck     = "latest:check"            // Unix timestamp from the latest time the worker checked this hash.
+
+	// Running total of clicks, only for links with max_clicks. Outside of metric hashes, so workers ignore it.
+	keyMetricTotal = "metric:link_total:%s:%s" // Ex.: metric:link_total:domain:keyword
+	metricTotalTTL = time.Hour                 // Total is counted again from history after that, fixing any drift.
+
+	layoutMetricCounter = "2006-01-02-15" // Go layout for hour in counter fields, always in UTC.
+
+	uniqueViolation = "23505" // Postgres error code for duplicated unique key.
+
+	granularityHour  = "hour"
+	granularityDay   = "day"
+	granularityWeek  = "week"
+	granularityMonth = "month"
+
+	maxClicksSeriesPoints = 1000
+
+	keyUnlockAttempts    = "link_unlock_attempts:%s:%s:%s" // Ex.: link_unlock_attempts:domain:keyword:ip
+	maxUnlockAttempts    = 5
+	unlockAttemptsWindow = 15 * time.Minute
 )
 
 // Service encapsulates the link service logic, http handlers and another transport layer.
 type Service interface {
-	FindRedirectURL(*gin.Context, string, string) (model.Link, error)
+	FindRedirectURL(*gin.Context, findFullURLRequest) (model.Link, error)
 	Add(*gin.Context, addRequest) (model.Link, error)
 	FindByID(*gin.Context, findByIDRequest) (model.Link, error)
-	FindAll(*gin.Context, findAllRequest) (int64, int, []model.Link, error)
-	Update(*gin.Context, updateRequest) error
+	FindAll(*gin.Context, findAllRequest) (int64, int, []model.Link, string, error)
+	Update(*gin.Context, updateRequest) (model.Link, error)
 	Delete(*gin.Context, deleteRequest) (err error)
 	FindFullURL(*gin.Context, string, string) (model.Link, error)
+	Unlock(*gin.Context, unlockRequest) (model.Link, error)
+	AddBatch(*gin.Context, batchAddRequest) ([]batchResult, error)
+	UpdateBatch(*gin.Context, batchUpdateRequest) ([]batchResult, error)
+	DeleteBatch(*gin.Context, batchDeleteRequest) ([]batchResult, error)
+	Export(*gin.Context, exportRequest, io.Writer) error
+	Import(*gin.Context, importRequest) ([]batchResult, error)
+	QRCode(*gin.Context, qrRequest) ([]byte, error)
+	SetTags(*gin.Context, tagsRequest) ([]model.Tag, error)
+	AddTags(*gin.Context, tagsRequest) ([]model.Tag, error)
+	RemoveTag(*gin.Context, tagRemoveRequest) error
+	History(*gin.Context, findByIDRequest) ([]model.LinkRevision, error)
+	Restore(*gin.Context, findByIDRequest) (model.Link, error)
+	PurgeTrash(context.Context, time.Duration) (int, error)
 	Clicks(*gin.Context, clicksRequest) (model.LinkClicks, error)
+	SyncClicks(context.Context, time.Duration) error
 
-	NewHTTP(*gin.Engine, *gin.RouterGroup)
+	NewWorker(context.Context, time.Duration)
+	NewTrashWorker(context.Context, time.Duration)
+	NewHTTP(*gin.Engine, *gin.RouterGroup, gin.HandlerFunc)
 	HTTPRedirect(*gin.Context)
 	HTTPAdd(*gin.Context)
 	HTTPFindByID(*gin.Context)
@@ -49,6 +94,19 @@ type Service interface {
 	HTTPUpdate(*gin.Context)
 	HTTPDelete(*gin.Context)
 	HTTPFindFullURL(*gin.Context)
+	HTTPUnlock(*gin.Context)
+	HTTPAddBatch(*gin.Context)
+	HTTPUpdateBatch(*gin.Context)
+	HTTPDeleteBatch(*gin.Context)
+	HTTPExport(*gin.Context)
+	HTTPImport(*gin.Context)
+	HTTPQRCode(*gin.Context)
+	HTTPSetTags(*gin.Context)
+	HTTPAddTags(*gin.Context)
+	HTTPRemoveTag(*gin.Context)
+	HTTPHistory(*gin.Context)
+	HTTPTrash(*gin.Context)
+	HTTPRestore(*gin.Context)
 	HTTPClicks(*gin.Context)
 }
 
@@ -63,52 +121,223 @@ func NewService(db *sql.DB, cache *redis.Client) Service {
 }
 
 // FindRedirectURL redirect to full link getting by domain and keyword combination.
-func (s service) FindRedirectURL(ctx *gin.Context, domain, keyword string) (m model.Link, err error) {
+// Link URL is replaced by destination of the targeting rule matching visitor, if any,
+// with country from IP address of visitor. Otherwise, by one of its variants.
+// Expired links return the link itself with e.ErrLinkExpired, so caller can use its fallback URL.
+// Links outside of their window return e.ErrLinkUnavailable, for their unavailable URL or page.
+// Protected links return e.ErrLinkPasswordRequired and are counted only after Unlock.
+func (s service) FindRedirectURL(ctx *gin.Context, payload findFullURLRequest) (m model.Link, err error) {
+	m, err = s.findByKeyword(ctx, payload.Domain, payload.Keyword)
+	if err != nil {
+		return
+	}
+
+	expired, err := s.expired(ctx, m)
+	if err != nil {
+		return
+	}
+
+	if expired {
+		return m, e.ErrLinkExpired
+	}
+
+	if ok, _ := available(m, time.Now()); !ok {
+		return m, e.ErrLinkUnavailable
+	}
+
+	if m.Protected {
+		return m, e.ErrLinkPasswordRequired
+	}
+
+	m = s.destination(ctx, m, payload.Click, payload.Variant)
+
+	// If found, increase counter in background process.
+	// Copy context because gin reuse it after the handler returns.
+	go increaseCounter(ctx.Copy(), s.cache, payload.Domain, payload.Keyword)
+	return
+}
+
+// Unlock check password from a protected link and return it to redirect.
+// Wrong passwords are limited by IP, so nobody can try all of them.
+func (s service) Unlock(ctx *gin.Context, payload unlockRequest) (m model.Link, err error) {
 	log := logger.Logger(ctx)
 
-	key := fmt.Sprintf(keyCacheShortLink, domain, keyword)
-	val, _ := itemFromCache(ctx, s.cache, key)
-	if val != "" {
-		m.URL = val
+	m, err = s.findByKeyword(ctx, payload.Domain, payload.Keyword)
+	if err != nil {
+		return
+	}
 
-		// If found, increase counter in background process.
-		go increaseCounter(ctx, s.cache, domain, keyword)
+	expired, err := s.expired(ctx, m)
+	if err != nil {
 		return
 	}
 
-	query := "SELECT url FROM links WHERE domain = $1 AND keyword = $2 AND active = true"
-	log.Debug().Caller().Msg(query)
+	if expired {
+		return m, e.ErrLinkExpired
+	}
 
-	err = s.db.QueryRowContext(ctx, query, domain, keyword).Scan(&m.URL)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return m, e.ErrLinkNotFound
+	if ok, _ := available(m, time.Now()); !ok {
+		return m, e.ErrLinkUnavailable
+	}
+
+	if !m.Protected {
+		m = s.destination(ctx, m, payload.Click, payload.Variant)
+		go increaseCounter(ctx.Copy(), s.cache, payload.Domain, payload.Keyword)
+		return
+	}
+
+	key := fmt.Sprintf(keyUnlockAttempts, payload.Domain, payload.Keyword, payload.Click.IP)
+
+	attempts, err := s.cache.Get(ctx, key).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Error().Caller().Msg(err.Error())
+		return model.Link{}, e.ErrInternalServerError
+	}
+
+	if attempts >= maxUnlockAttempts {
+		log.Warn().Caller().Msg(fmt.Sprintf("too many unlock attempts from '%s' to key '%s'", payload.Click.IP, key))
+		return model.Link{}, e.ErrLinkPasswordAttempts
+	}
+
+	if err = m.CheckPassword(payload.Password); err != nil {
+		log.Info().Caller().Msg(fmt.Sprintf("wrong password to link '%s'", m.ID))
+
+		// Window starts at first wrong attempt.
+		attempts, err := s.cache.Incr(ctx, key).Result()
+		if err == nil && attempts == 1 {
+			err = s.cache.Expire(ctx, key, unlockAttemptsWindow).Err()
+		}
+
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
 		}
+		return model.Link{}, e.ErrLinkPasswordWrong
+	}
 
+	// Keep going on error from cache.
+	if err = s.cache.Del(ctx, key).Err(); err != nil {
 		log.Error().Caller().Msg(err.Error())
+	}
+
+	m = s.destination(ctx, m, payload.Click, payload.Variant)
+	go increaseCounter(ctx.Copy(), s.cache, payload.Domain, payload.Keyword)
+	return m, nil
+}
+
+// Add create a new shortener link.
+func (s service) Add(c *gin.Context, payload addRequest) (link model.Link, err error) {
+	log := logger.Logger(c)
+
+	newLink, err := s.newLink(c, payload)
+	if err != nil {
 		return
 	}
 
-	// If found, increase counter in background process.
-	go increaseCounter(ctx, s.cache, domain, keyword)
+	// Link and its targets are saved together.
+	tx, err := s.db.BeginTx(c, &sql.TxOptions{})
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
 
-	status := s.cache.Set(ctx, key, m.URL, 10*time.Minute)
-	err = status.Err()
+	link, err = insertLink(c, tx, newLink)
 	if err != nil {
+		_ = tx.Rollback()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
 		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
 	}
+
+	s.uncache(c, link)
 	return
 }
 
-// Add create a new shortener link.
-func (s service) Add(c *gin.Context, payload addRequest) (link model.Link, err error) {
+// uncache remove links from cache, including keywords cached as not found.
+// Keep going on error from cache.
+func (s service) uncache(ctx context.Context, links ...model.Link) {
+	log := logger.Logger(ctx)
+
+	for _, link := range links {
+		key := fmt.Sprintf(keyCacheShortLink, link.Domain, link.Keyword)
+		if err := s.cache.Del(ctx, key).Err(); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+}
+
+// newLink validate payload and create a link ready to be inserted.
+func (s service) newLink(c *gin.Context, payload addRequest) (link model.Link, err error) {
 	log := logger.Logger(c)
 
-	if err = checkLink(payload.Domain, payload.Keyword, payload.URL); err != nil {
+	if err = checkLink(payload.Keyword, payload.URL); err != nil {
 		log.Error().Caller().Msg(err.Error())
 		return
 	}
 
+	if err = s.checkDomain(c, payload.WhoID, payload.Domain); err != nil {
+		return
+	}
+
+	// Column has no time zone and offset is lost when saved, so keep it in UTC.
+	// Every create path comes here: API, batch and imports.
+	if payload.ExpiresAt != nil {
+		t := payload.ExpiresAt.UTC()
+		payload.ExpiresAt = &t
+	}
+
+	if err = checkExpiration(payload.ExpiresAt, payload.MaxClicks, payload.FallbackURL); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkPassword(payload.Password); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkRedirectType(payload.RedirectType); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if payload.UTMPreset != "" {
+		preset, err := s.findUTMPreset(c, payload.WhoID, payload.UTMPreset)
+		if err != nil {
+			return link, err
+		}
+		payload.UTM = payload.UTM.Merge(preset)
+	}
+
+	if !payload.UTM.Valid() {
+		log.Warn().Caller().Msg(e.ErrLinkInvalidUTM.Error())
+		return link, e.ErrLinkInvalidUTM
+	}
+
+	payload.Targets = normalizeTargets(payload.Targets)
+	if err = checkTargets(payload.Targets); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	payload.Variants = normalizeVariants(payload.Variants, nil)
+	if err = checkVariants(payload.Variants); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	payload.StartsAt, payload.EndsAt, payload.Schedule = normalizeSchedule(payload.StartsAt, payload.EndsAt, payload.Schedule)
+	if err = checkSchedule(payload.StartsAt, payload.EndsAt, payload.Schedule, payload.UnavailableURL); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = s.checkFolder(c, payload.WhoID, payload.FolderID); err != nil {
+		return
+	}
+
 	// If user is anonymous, create a random ID and blank another fields.
 	if payload.WhoID == "0" {
 		sid, _ := shortid.New(1, shortid.DefaultABC, 2342)
@@ -123,37 +352,54 @@ func (s service) Add(c *gin.Context, payload addRequest) (link model.Link, err e
 		}
 	}
 
-	query := "SELECT id FROM links WHERE domain = $1 AND keyword = $2 LIMIT 1"
-	err = s.db.QueryRowContext(c, query, payload.Domain, payload.Keyword).Scan(&link.ID)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
+	// Create a new link getting info from payload.
+	// Maybe we can change this to a more elegant way.
+	link.ID = ulid.Make().String()
+	link.Domain = payload.Domain
+	link.Keyword = payload.Keyword
+	link.URL = payload.URL
+	link.Title = payload.Title
+	link.UserID = payload.WhoID
+	link.ExpiresAt = payload.ExpiresAt
+	link.MaxClicks = payload.MaxClicks
+	link.FallbackURL = payload.FallbackURL
+	link.RedirectType = payload.RedirectType
+	link.ForwardQuery = payload.ForwardQuery
+	link.ForwardPath = payload.ForwardPath
+	link.UTM = payload.UTM
+	link.Targets = payload.Targets
+	link.Variants = payload.Variants
+	link.StickyVariant = payload.StickyVariant
+	link.StartsAt = payload.StartsAt
+	link.EndsAt = payload.EndsAt
+	link.Schedule = payload.Schedule
+	link.UnavailableURL = payload.UnavailableURL
+	link.FolderID = payload.FolderID
+
+	if payload.Password != "" {
+		if err = link.HashPassword(payload.Password); err != nil {
 			log.Error().Caller().Msg(err.Error())
-			return
+			return link, e.ErrInternalServerError
 		}
 	}
+	return
+}
 
-	if link.ID != "" {
-		message := fmt.Sprintf("link with domain '%s' and keyword '%s' already exists", payload.Domain, payload.Keyword)
-		log.Warn().Caller().Msg(message)
-		return link, e.ErrLinkAlreadyExists
-	}
+// insertLink save a new link with database or transaction.
+// Existent domain and keyword combination is checked in the same statement.
+func insertLink(c context.Context, q queryer, newLink model.Link) (link model.Link, err error) {
+	log := logger.Logger(c)
 
-	query = `
-		INSERT INTO links(id, domain, keyword, url, title, user_id) 
-		VALUES($1, $2, $3, $4, $5, $6)
-	`
+	query := `
+		INSERT INTO links(id, domain, keyword, url, title, user_id, expires_at, max_clicks, fallback_url, password,
+			redirect_type, forward_query, forward_path, utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+			sticky_variant, starts_at, ends_at, schedule, unavailable_url, folder_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+			NULLIF($22, '')::JSONB, $23, NULLIF($24, ''))
+		ON CONFLICT (domain, keyword) DO NOTHING
+		RETURNING ` + linkColumns
 
-	// Create a new link getting info from payload.
-	// Maybe we can change this to a more elegant way.
-	newLink := model.Link{}
-	newLink.ID = ulid.Make().String()
-	newLink.Domain = payload.Domain
-	newLink.Keyword = payload.Keyword
-	newLink.URL = payload.URL
-	newLink.Title = payload.Title
-	newLink.UserID = payload.WhoID
-
-	_, err = s.db.ExecContext(
+	row := q.QueryRowContext(
 		c,
 		query,
 		newLink.ID,
@@ -162,26 +408,50 @@ func (s service) Add(c *gin.Context, payload addRequest) (link model.Link, err e
 		newLink.URL,
 		newLink.Title,
 		newLink.UserID,
+		newLink.ExpiresAt,
+		newLink.MaxClicks,
+		newLink.FallbackURL,
+		newLink.Password,
+		newLink.RedirectType,
+		newLink.ForwardQuery,
+		newLink.ForwardPath,
+		newLink.Source,
+		newLink.Medium,
+		newLink.Campaign,
+		newLink.Term,
+		newLink.Content,
+		newLink.StickyVariant,
+		newLink.StartsAt,
+		newLink.EndsAt,
+		scheduleValue(newLink.Schedule),
+		newLink.UnavailableURL,
+		newLink.FolderID,
 	)
+
+	err = scanLink(row, &link)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			message := fmt.Sprintf("link with domain '%s' and keyword '%s' already exists", newLink.Domain, newLink.Keyword)
+			log.Warn().Caller().Msg(message)
+			return link, e.ErrLinkAlreadyExists
+		}
+
 		log.Error().Caller().Msg(err.Error())
-		return
+		return link, e.ErrInternalServerError
 	}
 
-	query = `SELECT id, user_id, created_at, updated_at, domain, keyword, url, title, active FROM links WHERE id = $1`
-	err = s.db.QueryRowContext(c, query, newLink.ID).Scan(
-		&link.ID,
-		&link.UserID,
-		&link.CreatedAt,
-		&link.UpdatedAtNull,
-		&link.Domain,
-		&link.Keyword,
-		&link.URL,
-		&link.Title,
-		&link.Active)
-	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return
+	if len(newLink.Targets) > 0 {
+		if err = setTargets(c, q, link.ID, newLink.Targets); err != nil {
+			return
+		}
+		link.Targets = newLink.Targets
+	}
+
+	if len(newLink.Variants) > 0 {
+		if err = setVariants(c, q, link.ID, newLink.Variants); err != nil {
+			return
+		}
+		link.Variants = newLink.Variants
 	}
 	return
 }
@@ -190,77 +460,143 @@ func (s service) Add(c *gin.Context, payload addRequest) (link model.Link, err e
 func (s service) FindByID(c *gin.Context, payload findByIDRequest) (link model.Link, err error) {
 	log := logger.Logger(c)
 
-	query := "SELECT * FROM links WHERE user_id = $1 AND id = $2 LIMIT 1"
-	rows, err := s.db.QueryContext(c, query, payload.WhoID, payload.LinkID)
+	query := "SELECT " + linkColumns + " FROM links WHERE user_id = $1 AND id = $2 LIMIT 1"
+	err = scanLink(s.db.QueryRowContext(c, query, payload.WhoID, payload.LinkID), &link)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Debug().Caller().Msg("link not found")
+			return link, e.ErrLinkNotFound
+		}
+
 		log.Error().Caller().Msg(err.Error())
 		return
 	}
 
-	defer rows.Close()
-	if rows.Next() {
-		err = rows.Scan(
-			&link.ID,
-			&link.UserID,
-			&link.CreatedAt,
-			&link.UpdatedAt,
-			&link.Domain,
-			&link.Keyword,
-			&link.URL,
-			&link.Title,
-			&link.Active,
-		)
-		if err != nil {
-			log.Error().Caller().Msg(err.Error())
-			return
-		}
+	tags, err := s.linkTags(c, []string{link.ID})
+	if err != nil {
+		return
+	}
 
-		log.Debug().Caller().Msg(fmt.Sprintf("link_id=%s", link.ID))
+	link.Tags = tags[link.ID]
+
+	if link.Targets, err = linkTargets(c, s.db, link.ID); err != nil {
+		return
+	}
+
+	if link.Variants, err = linkVariants(c, s.db, link.ID); err != nil {
 		return
 	}
 
-	log.Debug().Caller().Msg("link not found")
-	return link, e.ErrLinkNotFound
+	log.Debug().Caller().Msg(fmt.Sprintf("link_id=%s", link.ID))
+	return
 }
 
 // FindAll get a list of links from database.
-func (s service) FindAll(ctx *gin.Context, payload findAllRequest) (total int64, pages int, links []model.Link, err error) {
+func (s service) FindAll(ctx *gin.Context, payload findAllRequest) (total int64, pages int, links []model.Link, next string, err error) {
 	log := logger.Logger(ctx)
 
-	queryCount := `SELECT COUNT(0) FROM links 
-                WHERE user_id = $1
-	`
-	log.Debug().Caller().Msg(queryCount)
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{payload.WhoID}
 
-	queryData := `SELECT id, user_id, created_at, updated_at, domain, keyword, url, title, active 
-		FROM links
-		WHERE user_id = $1
-		ORDER BY $2 OFFSET $3 LIMIT $4
-	`
-	log.Debug().Caller().Msg(queryData)
+	if payload.Trash {
+		conditions[1] = "deleted_at IS NOT NULL"
+	}
 
-	//domain, keyword := common.SplitURL(payload.ShortenedURL)
+	if payload.TagID != "" {
+		args = append(args, payload.TagID)
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT link_id FROM link_tags WHERE tag_id = $%d)", len(args)))
+	}
 
-	err = s.db.QueryRowContext(
-		ctx,
-		queryCount,
-		payload.WhoID,
-		//payload.SearchText,
-		//domain,
-		//keyword,
-	).Scan(&total)
-	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return
+	if payload.FolderID != "" {
+		args = append(args, payload.FolderID)
+		conditions = append(conditions, fmt.Sprintf("folder_id = $%d", len(args)))
+	}
+
+	if query := searchQuery(payload.SearchText); query != "" {
+		args = append(args, query)
+		conditions = append(conditions, fmt.Sprintf("search @@ to_tsquery('simple', $%d)", len(args)))
+	}
+
+	if payload.ShortenedURL != "" {
+		domain, keyword := common.SplitURL(payload.ShortenedURL)
+		if keyword == "" {
+			keyword = payload.ShortenedURL
+		}
+
+		args = append(args, keyword)
+		conditions = append(conditions, fmt.Sprintf("keyword = $%d", len(args)))
+
+		if domain != "" {
+			args = append(args, domain)
+			conditions = append(conditions, fmt.Sprintf("domain = $%d", len(args)))
+		}
+	}
+
+	if payload.Domain != "" {
+		args = append(args, payload.Domain)
+		conditions = append(conditions, fmt.Sprintf("domain = $%d", len(args)))
+	}
+
+	if payload.Active != nil {
+		args = append(args, *payload.Active)
+		conditions = append(conditions, fmt.Sprintf("active = $%d", len(args)))
+	}
+
+	if payload.CreatedFrom != nil {
+		args = append(args, *payload.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if payload.CreatedTo != nil {
+		args = append(args, *payload.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	// Columns can't be bound parameters, so sort comes from a whitelist.
+	column, ok := sortColumns[payload.SortBy]
+	if !ok {
+		return total, pages, links, next, e.ErrLinkInvalidSort
+	}
+
+	order, compare := "ASC", ">"
+	if payload.Order == "desc" {
+		order, compare = "DESC", "<"
+	}
+
+	// Total is only needed by page numbers, cursors skip the count.
+	if payload.Cursor.IsZero() {
+		queryCount := `SELECT COUNT(0) FROM links WHERE ` + strings.Join(conditions, " AND ")
+		log.Debug().Caller().Msg(queryCount)
+
+		err = s.db.QueryRowContext(ctx, queryCount, args...).Scan(&total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+	} else {
+		args = append(args, payload.Cursor.ID)
+
+		if payload.SortBy == "id" {
+			conditions = append(conditions, fmt.Sprintf("id %s $%d", compare, len(args)))
+		} else {
+			args = append(args, payload.Cursor.Value)
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, compare, len(args), len(args)-1))
+		}
+		payload.Offset = 0
 	}
 
+	// One more row than limit tell us if there is a next page.
+	queryData := `SELECT ` + linkColumns + `, ` + linkClicks + `
+		FROM links
+		WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY %s %s, id %s OFFSET $%d LIMIT $%d
+	`, column, order, order, len(args)+1, len(args)+2)
+	log.Debug().Caller().Msg(queryData)
+
 	rows, err := s.db.QueryContext(
 		ctx,
 		queryData,
-		payload.WhoID,
-		payload.Sort,
-		payload.Offset,
-		payload.Limit,
+		append(args, payload.Offset, payload.Limit+1)...,
 	)
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
@@ -269,119 +605,378 @@ func (s service) FindAll(ctx *gin.Context, payload findAllRequest) (total int64,
 
 	defer rows.Close()
 	links = []model.Link{}
-	link := model.Link{}
+	ids := []string{}
 
 	for rows.Next() {
-		err = rows.Scan(
-			&link.ID,
-			&link.UserID,
-			&link.CreatedAt,
-			&link.UpdatedAtNull,
-			&link.Domain,
-			&link.Keyword,
-			&link.URL,
-			&link.Title,
-			&link.Active,
-		)
+		link := model.Link{}
+
+		err = scanLink(rows, &link, &link.Clicks.Total)
 		if err != nil {
 			log.Error().Caller().Msg(err.Error())
 			return
 		}
 
-		if link.UpdatedAtNull.Valid {
-			link.UpdatedAt = &link.UpdatedAtNull.Time
-		}
-
-		//clicks, err := s.Clicks(ctx, clicksRequest{
-		//	WhoID:    payload.WhoID,
-		//	ShortURL: fmt.Sprintf("%s/%s", link.Domain, link.Keyword),
-		//})
-		//if err == nil {
-		//	link.Clicks = clicks
-		//}
-
 		links = append(links, link)
+		ids = append(ids, link.ID)
 	}
 
 	if err != nil {
 		log.Error().Caller().Msg(err.Error())
-		return total, pages, links, e.ErrInternalServerError
+		return total, pages, links, next, e.ErrInternalServerError
 	}
 
-	pages = int(math.Ceil(float64(total) / float64(payload.Limit)))
-	return
-}
-
-// Update change specific link by ID.
-func (s service) Update(ctx *gin.Context, payload updateRequest) (err error) {
-	log := logger.Logger(ctx)
+	if len(links) > payload.Limit {
+		links, ids = links[:payload.Limit], ids[:payload.Limit]
+		last := links[len(links)-1]
 
-	query := "UPDATE links SET title = $1 WHERE id = $2 AND user_id = $3"
-	log.Debug().Caller().Msg(query)
+		next = cursor.Cursor{
+			ID:    last.ID,
+			Sort:  payload.Sort,
+			Value: sortValue(last, payload.SortBy),
+		}.String()
+	}
 
-	_, err = s.db.ExecContext(ctx, query, payload.Title, payload.LinkID, payload.WhoID)
+	tags, err := s.linkTags(ctx, ids)
 	if err != nil {
-		log.Error().Caller().Msg(err.Error())
-		return e.ErrInternalServerError
+		return
+	}
+
+	for i := range links {
+		links[i].Tags = tags[links[i].ID]
+	}
+
+	if payload.Cursor.IsZero() {
+		pages = int(math.Ceil(float64(total) / float64(payload.Limit)))
 	}
 	return
 }
 
-// Delete delete a link by ID.
-func (s service) Delete(ctx *gin.Context, payload deleteRequest) (err error) {
+// Update change title, destination, keyword, domain or active state of a link.
+// Each changed field is saved as a revision, in the same transaction.
+func (s service) Update(ctx *gin.Context, payload updateRequest) (link model.Link, err error) {
 	log := logger.Logger(ctx)
-	link := model.Link{}
-
-	query := "SELECT id, domain, keyword FROM links WHERE user_id = $1 AND id = $2 AND active = true"
-	err = s.db.QueryRowContext(ctx, query, payload.WhoID, payload.LinkID).Scan(&link.ID, &link.Domain, &link.Keyword)
 
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Debug().Caller().Msg(fmt.Sprintf("Link with id=%s was not found", payload.LinkID))
-			return e.ErrLinkNotFound
-		}
-
 		log.Error().Caller().Msg(err.Error())
-		return e.ErrInternalServerError
+		return link, e.ErrInternalServerError
 	}
 
-	key := fmt.Sprintf("link_%s_%s", link.Domain, link.Keyword)
-	_, err = s.cache.Del(ctx, key).Result()
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	// Keep going on error from cache.
-	// Because SQL database still working, so cache doesn't matter at this moment.
+	old, link, changed, err := s.updateLink(ctx, tx, payload)
 	if err != nil {
-		log.Error().Caller().Msg(err.Error())
+		return
 	}
 
-	query = "UPDATE links SET active = false, updated_at = $1 WHERE user_id = $2 AND id = $3"
-	log.Debug().Caller().Msg(query)
+	if !changed {
+		_ = tx.Rollback()
+		return old, nil
+	}
 
-	_, err = s.db.ExecContext(ctx, query, time.Now(), payload.WhoID, payload.LinkID)
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		log.Error().Caller().Msg(err.Error())
-		return e.ErrInternalServerError
+		return link, e.ErrInternalServerError
 	}
-	return
-}
 
-// FindFullURL get a shortener link from keyword.
-func (s service) FindFullURL(c *gin.Context, domain, keyword string) (m model.Link, err error) {
-	log := logger.Logger(c)
+	s.updated(ctx, old, link)
 
-	key := fmt.Sprintf(keyCacheShortLink, domain, keyword)
-	val, _ := itemFromCache(c, s.cache, key)
-	if val != "" {
-		m.URL = val
+	tags, err := s.linkTags(ctx, []string{link.ID})
+	if err != nil {
 		return
 	}
 
-	query := "SELECT url FROM links WHERE domain = $1 AND keyword = $2 AND active = true"
-	log.Debug().Caller().Msg(query)
-
-	err = s.db.QueryRowContext(c, query, domain, keyword).Scan(&m.URL)
+	link.Tags = tags[link.ID]
+	return
+}
+
+// updateLink change a link inside "tx", with its revisions. Nothing is changed when "payload" has
+// the same values as link, so "changed" is false and "link" is the old one.
+func (s service) updateLink(ctx *gin.Context, tx *sql.Tx, payload updateRequest) (old, link model.Link, changed bool, err error) {
+	log := logger.Logger(ctx)
+
+	query := "SELECT " + linkColumns + " FROM links WHERE id = $1 AND user_id = $2 FOR UPDATE"
+
+	err = scanLink(tx.QueryRowContext(ctx, query, payload.LinkID, payload.WhoID), &old)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			return old, link, false, e.ErrLinkNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return old, link, false, e.ErrInternalServerError
+	}
+
+	if old.Targets, err = linkTargets(ctx, tx, old.ID); err != nil {
+		return
+	}
+
+	if old.Variants, err = linkVariants(ctx, tx, old.ID); err != nil {
+		return
+	}
+
+	next := old
+	if payload.Title != nil {
+		next.Title = *payload.Title
+	}
+	if payload.URL != nil {
+		next.URL = *payload.URL
+	}
+	if payload.Keyword != nil {
+		next.Keyword = *payload.Keyword
+	}
+	if payload.Domain != nil {
+		next.Domain = *payload.Domain
+	}
+	if payload.Active != nil {
+		next.Active = strconv.FormatBool(*payload.Active)
+	}
+	if payload.RedirectType != nil {
+		next.RedirectType = *payload.RedirectType
+	}
+	if payload.ForwardQuery != nil {
+		next.ForwardQuery = *payload.ForwardQuery
+	}
+	if payload.ForwardPath != nil {
+		next.ForwardPath = *payload.ForwardPath
+	}
+	if payload.Targets != nil {
+		next.Targets = normalizeTargets(*payload.Targets)
+	}
+	if payload.Variants != nil {
+		next.Variants = normalizeVariants(*payload.Variants, old.Variants)
+	}
+	if payload.StickyVariant != nil {
+		next.StickyVariant = *payload.StickyVariant
+	}
+	if payload.StartsAt.Set {
+		next.StartsAt = payload.StartsAt.Value
+	}
+	if payload.EndsAt.Set {
+		next.EndsAt = payload.EndsAt.Value
+	}
+	if payload.Schedule.Set {
+		next.Schedule = payload.Schedule.Value
+	}
+	if payload.UnavailableURL != nil {
+		next.UnavailableURL = *payload.UnavailableURL
+	}
+	if payload.FolderID != nil {
+		next.FolderID = *payload.FolderID
+	}
+	next.StartsAt, next.EndsAt, next.Schedule = normalizeSchedule(next.StartsAt, next.EndsAt, next.Schedule)
+
+	revisions := diffLink(old, next)
+	if len(revisions) == 0 {
+		return old, old, false, nil
+	}
+
+	if err = checkLink(next.Keyword, next.URL); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkRedirectType(next.RedirectType); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkTargets(next.Targets); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkVariants(next.Variants); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if err = checkSchedule(next.StartsAt, next.EndsAt, next.Schedule, next.UnavailableURL); err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return
+	}
+
+	if next.Domain != old.Domain {
+		if err = s.checkDomain(ctx, payload.WhoID, next.Domain); err != nil {
+			return
+		}
+	}
+
+	if next.FolderID != old.FolderID {
+		if err = s.checkFolder(ctx, payload.WhoID, next.FolderID); err != nil {
+			return
+		}
+	}
+
+	// Active links can't stay in trash.
+	query = `UPDATE links SET title = $1, url = $2, keyword = $3, domain = $4, active = $5, updated_at = $6,
+		deleted_at = CASE WHEN $5 THEN NULL ELSE deleted_at END, redirect_type = $9, forward_query = $10,
+		forward_path = $11, sticky_variant = $12, starts_at = $13, ends_at = $14, schedule = NULLIF($15, '')::JSONB,
+		unavailable_url = $16, folder_id = NULLIF($17, '')
+		WHERE id = $7 AND user_id = $8
+		RETURNING ` + linkColumns
+	log.Debug().Caller().Msg(query)
+
+	row := tx.QueryRowContext(ctx, query, next.Title, next.URL, next.Keyword, next.Domain,
+		next.Active, time.Now(), payload.LinkID, payload.WhoID, next.RedirectType, next.ForwardQuery,
+		next.ForwardPath, next.StickyVariant, next.StartsAt, next.EndsAt, scheduleValue(next.Schedule),
+		next.UnavailableURL, next.FolderID)
+
+	if err = scanLink(row, &link); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return old, link, false, e.ErrLinkAlreadyExists
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return old, link, false, e.ErrInternalServerError
+	}
+
+	if payload.Targets != nil {
+		if err = setTargets(ctx, tx, link.ID, next.Targets); err != nil {
+			return
+		}
+	}
+	link.Targets = next.Targets
+
+	if payload.Variants != nil {
+		if err = setVariants(ctx, tx, link.ID, next.Variants); err != nil {
+			return
+		}
+	}
+	link.Variants = next.Variants
+
+	if err = insertRevisions(ctx, tx, link.ID, payload.WhoID, revisions); err != nil {
+		return
+	}
+
+	return old, link, true, nil
+}
+
+// updated remove old and new short URLs of an updated link from cache and move its counters.
+// Keep going on error from cache, database is already updated.
+func (s service) updated(ctx context.Context, old, link model.Link) {
+	log := logger.Logger(ctx)
+
+	// Old short URL must stop to redirect from cache, and new one must not use an old entry.
+	keys := []string{
+		fmt.Sprintf(keyCacheShortLink, old.Domain, old.Keyword),
+		fmt.Sprintf(keyCacheShortLink, link.Domain, link.Keyword),
+	}
+
+	moved := old.Domain != link.Domain || old.Keyword != link.Keyword
+	if moved {
+		keys = append(keys,
+			fmt.Sprintf(keyCacheShortLinkMetricCounterHours, old.Domain, old.Keyword),
+			fmt.Sprintf(keyCacheShortLinkMetricCounterHours, link.Domain, link.Keyword),
+			fmt.Sprintf(keyMetricTotal, old.Domain, old.Keyword),
+			fmt.Sprintf(keyMetricTotal, link.Domain, link.Keyword),
+		)
+	}
+
+	if err := s.cache.Del(ctx, keys...).Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	// Counters not synced yet go with the link, otherwise the worker can't find their link anymore
+	// and a new link with the old keyword would get them.
+	if moved {
+		if err := moveCounters(ctx, s.cache, old.Domain, old.Keyword, link.Domain, link.Keyword); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+}
+
+// Delete move a link to trash. It can be restored until purged by retention.
+func (s service) Delete(ctx *gin.Context, payload deleteRequest) (err error) {
+	log := logger.Logger(ctx)
+	link := model.Link{}
+
+	// Self join return the active state before update, to save it in revisions.
+	query := `UPDATE links l SET active = false, deleted_at = $1, updated_at = $1 FROM links old
+		WHERE old.id = l.id AND l.user_id = $2 AND l.id = $3 AND l.deleted_at IS NULL
+		RETURNING l.id, l.domain, l.keyword, old.active`
+	log.Debug().Caller().Msg(query)
+
+	err = s.db.QueryRowContext(ctx, query, time.Now(), payload.WhoID, payload.LinkID).
+		Scan(&link.ID, &link.Domain, &link.Keyword, &link.Active)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Debug().Caller().Msg(fmt.Sprintf("Link with id=%s was not found", payload.LinkID))
+			return e.ErrLinkNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	key := fmt.Sprintf(keyCacheShortLink, link.Domain, link.Keyword)
+	_, err = s.cache.Del(ctx, key).Result()
+
+	// Keep going on error from cache.
+	// Because SQL database still working, so cache doesn't matter at this moment.
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	revisions := diffLink(link, model.Link{Domain: link.Domain, Keyword: link.Keyword, Active: "false"})
+	return insertRevisions(ctx, s.db, link.ID, payload.WhoID, revisions)
+}
+
+// FindFullURL get a shortener link from keyword.
+// Destination of protected links is not showed here.
+func (s service) FindFullURL(c *gin.Context, domain, keyword string) (m model.Link, err error) {
+	m, err = s.findByKeyword(c, domain, keyword)
+	if err != nil {
+		return
+	}
+
+	if m.Protected {
+		return model.Link{}, e.ErrLinkPasswordRequired
+	}
+	return
+}
+
+// findByKeyword get an active link from cache or, if not cached, from database.
+// Link is cached for 10 minutes with everything needed to redirect.
+func (s service) findByKeyword(ctx context.Context, domain, keyword string) (m model.Link, err error) {
+	log := logger.Logger(ctx)
+
+	key := fmt.Sprintf(keyCacheShortLink, domain, keyword)
+	cached := cachedLink{}
+
+	val, _ := itemFromCache(ctx, s.cache, key)
+	if val == cacheNotFound {
+		return m, e.ErrLinkNotFound
+	}
+
+	if val != "" {
+		if err = json.Unmarshal([]byte(val), &cached); err == nil {
+			m = cached.Link
+			m.Password = cached.Password
+			return
+		}
+
+		// Maybe an old format of cache, so get from database again.
+		log.Warn().Caller().Msg(fmt.Sprintf("invalid cache value in key '%s': %s", key, err.Error()))
+	}
+
+	query := "SELECT " + linkColumns + " FROM links WHERE domain = $1 AND keyword = $2 AND active = true"
+	log.Debug().Caller().Msg(query)
+
+	err = scanLink(s.db.QueryRowContext(ctx, query, domain, keyword), &m)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Unknown paths are requested a lot, like by crawlers, so misses are cached too.
+			// Keep going on error from cache.
+			if err := s.cache.Set(ctx, key, cacheNotFound, cacheNotFoundTTL).Err(); err != nil {
+				log.Error().Caller().Msg(err.Error())
+			}
 			return m, e.ErrLinkNotFound
 		}
 
@@ -389,59 +984,472 @@ func (s service) FindFullURL(c *gin.Context, domain, keyword string) (m model.Li
 		return
 	}
 
-	status := s.cache.Set(c, key, m.URL, 10*time.Minute)
-	err = status.Err()
+	// Targets and variants are cached with link, so redirects don't need database.
+	if m.Targets, err = linkTargets(ctx, s.db, m.ID); err != nil {
+		return
+	}
+
+	if m.Variants, err = linkVariants(ctx, s.db, m.ID); err != nil {
+		return
+	}
+
+	// Password hash is not in Link JSON, but we need it to unlock.
+	b, _ := json.Marshal(cachedLink{Link: m, Password: m.Password})
+
+	// Keep going on error from cache.
+	err = s.cache.Set(ctx, key, b, cacheTTL(m, time.Now())).Err()
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+	return m, nil
+}
+
+// checkDomain permit public domains to everyone and custom domains
+// only to their users, after verification.
+func (s service) checkDomain(ctx context.Context, whoID, domain string) (err error) {
+	log := logger.Logger(ctx)
+
+	if publicDomain(domain) {
+		return nil
+	}
+
+	if whoID == "0" {
+		return e.ErrLinkInvalidDomain
+	}
+
+	query := `SELECT COUNT(0) FROM domains WHERE name = $1 AND verified_at IS NOT NULL
+		AND (user_id = $2 OR group_id IN (SELECT group_id FROM group_user WHERE user_id = $2))`
+
+	total := 0
+	err = s.db.QueryRowContext(ctx, query, domain, whoID).Scan(&total)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if total == 0 {
+		log.Warn().Caller().Msg(fmt.Sprintf("domain '%s' is not verified for user '%s'", domain, whoID))
+		return e.ErrLinkInvalidDomain
+	}
+	return nil
+}
+
+// checkFolder permit only folders from user. Empty is no folder.
+func (s service) checkFolder(ctx context.Context, whoID, folderID string) (err error) {
+	log := logger.Logger(ctx)
+
+	if folderID == "" {
+		return nil
+	}
+
+	total := 0
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(0) FROM folders WHERE id = $1 AND user_id = $2", folderID, whoID).Scan(&total)
 	if err != nil {
-		fmt.Println(err.Error())
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if total == 0 {
+		return e.ErrFolderNotFound
+	}
+	return nil
+}
+
+// findUTMPreset get a preset from user or from one of its groups.
+func (s service) findUTMPreset(ctx context.Context, whoID, presetID string) (utm model.UTM, err error) {
+	log := logger.Logger(ctx)
+
+	if whoID == "0" {
+		return utm, e.ErrUTMPresetNotFound
+	}
+
+	query := `SELECT utm_source, utm_medium, utm_campaign, utm_term, utm_content FROM utm_presets
+		WHERE id = $1 AND (user_id = $2 OR group_id IN (SELECT group_id FROM group_user WHERE user_id = $2))`
+
+	err = s.db.QueryRowContext(ctx, query, presetID, whoID).
+		Scan(&utm.Source, &utm.Medium, &utm.Campaign, &utm.Term, &utm.Content)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn().Caller().Msg(fmt.Sprintf("UTM preset '%s' not found for user '%s'", presetID, whoID))
+			return utm, e.ErrUTMPresetNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return utm, e.ErrInternalServerError
 	}
 	return
 }
 
+// cachedLink is how a link stays in cache, with its password hash.
+type cachedLink struct {
+	model.Link
+	Password string `json:"password_hash"`
+}
+
+// expired check if link passed its expiration date or its maximum of clicks.
+func (s service) expired(ctx context.Context, m model.Link) (bool, error) {
+	if m.ExpiresAt != nil && !time.Now().Before(*m.ExpiresAt) {
+		return true, nil
+	}
+
+	if m.MaxClicks <= 0 {
+		return false, nil
+	}
+
+	total, err := s.totalClicks(ctx, m.Domain, m.Keyword)
+	if err != nil {
+		return false, err
+	}
+
+	return total >= m.MaxClicks, nil
+}
+
+// totalClicks get running total of clicks from cache. Without it, total is counted
+// from history only once and increaseCounter keeps it updated.
+func (s service) totalClicks(ctx context.Context, domain, keyword string) (total int, err error) {
+	log := logger.Logger(ctx)
+	key := fmt.Sprintf(keyMetricTotal, domain, keyword)
+
+	total, err = s.cache.Get(ctx, key).Int()
+	if err == nil {
+		return
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		// Keep going on error from cache, history is still there.
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	hours, err := s.clicksByHour(ctx, domain, keyword)
+	if err != nil {
+		return
+	}
+
+	total = 0
+	for _, value := range hours {
+		total += value
+	}
+
+	// Another request can be faster, so keep the first total and its increments.
+	if err := s.cache.SetNX(ctx, key, total, metricTotalTTL).Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+	return total, nil
+}
+
+// Clicks get total of clicks from a short link and a series of clicks between
+// timestamps "from" and "to", grouped by hour, day, week or month.
 func (s service) Clicks(ctx *gin.Context, payload clicksRequest) (lc model.LinkClicks, err error) {
 	log := logger.Logger(ctx)
 
+	// Only owner of link can see its clicks. Anonymous links have no owner.
+	if payload.WhoID == "0" {
+		return lc, e.ErrUnauthorized
+	}
+
+	domain, keyword := common.SplitURL(payload.ShortURL)
+
+	owned := 0
+	query := "SELECT COUNT(0) FROM links WHERE user_id = $1 AND domain = $2 AND keyword = $3"
+
+	err = s.db.QueryRowContext(ctx, query, payload.WhoID, domain, keyword).Scan(&owned)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return lc, e.ErrInternalServerError
+	}
+
+	if owned == 0 {
+		return lc, e.ErrLinkNotFound
+	}
+
 	// Check if this key exists in cache,
 	// if not, set with expiration for 10 seconds.
-	domain, keyword := common.SplitURL(payload.ShortURL)
-	keyCache := fmt.Sprintf(keyCacheShortLinkMetricCounterTotal, domain, keyword)
+	keyCache := fmt.Sprintf(keyCacheShortLinkMetricCounterHours, domain, keyword)
+
+	hours := map[string]int{}
 
 	val, _ := itemFromCache(ctx, s.cache, keyCache)
 	if val != "" {
-		total, err := strconv.Atoi(val)
+		err = json.Unmarshal([]byte(val), &hours)
+	}
+
+	if val == "" || err != nil {
+		hours, err = s.clicksByHour(ctx, domain, keyword)
 		if err != nil {
-			return lc, err
+			return
 		}
 
-		lc.Total = total
-		return lc, err
+		// Send item to cache async.
+		// But whatever if errors happens.
+		b, _ := json.Marshal(hours)
+		go func() {
+			err := setItemToCache(ctx, s.cache, keyCache, b)
+			if err != nil {
+				log.Error().Caller().Msg(fmt.Sprintf("Error to create cache: %s", err.Error()))
+			}
+		}()
+	}
+
+	for _, value := range hours {
+		lc.Total += value
+	}
+
+	lc.Series, err = clicksSeries(hours, payload.TimestampFrom, payload.TimestampTo, payload.Granularity)
+	if err != nil {
+		return
+	}
+
+	lc.Variants, err = s.variantClicks(ctx, domain, keyword, payload.TimestampFrom, payload.TimestampTo)
+	return
+}
+
+// clicksByHour get click counters grouped by hour (yyyy-mm-dd-hh).
+// Persisted history come from database and counters still in cache override the same hour,
+// because cache is always newer. So, this works with a cold cache too.
+func (s service) clicksByHour(ctx context.Context, domain, keyword string) (hours map[string]int, err error) {
+	log := logger.Logger(ctx)
+	hours = map[string]int{}
+
+	query := `SELECT h.bucket, h.total FROM links_clicks_hourly h
+		INNER JOIN links l ON l.id = h.link_id
+		WHERE l.domain = $1 AND l.keyword = $2
+	`
+	log.Debug().Caller().Msg(query)
+
+	rows, err := s.db.QueryContext(ctx, query, domain, keyword)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket time.Time
+		var total int
+
+		err = rows.Scan(&bucket, &total)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return
+		}
+
+		hours[bucket.Format(layoutMetricCounter)] = total
 	}
 
 	key := fmt.Sprintf(keyMetricShortLink, domain, keyword)
 	log.Debug().Caller().Msg(key)
 
-	cmd := s.cache.HVals(ctx, key)
-	result, err := cmd.Result()
+	result, err := s.cache.HGetAll(ctx, key).Result()
 	if err != nil {
+		// Keep going on error from cache, we still have the persisted history.
 		log.Error().Caller().Msg(err.Error())
-		return
+		return hours, nil
 	}
 
-	total := 0
-	for _, value := range result {
+	prefix := fmt.Sprintf(keyMetricCounter, "")
+	for field, value := range result {
+		if !strings.HasPrefix(field, prefix) {
+			continue
+		}
+
 		i, _ := strconv.Atoi(value)
-		total += i
+		hours[strings.TrimPrefix(field, prefix)] = i
 	}
 
-	// Send item to cache async.
-	// But whatever if errors happens.
-	go func() {
-		err := setItemToCache(ctx, s.cache, keyCache, total)
-		if err != nil {
-			log.Error().Caller().Msg(fmt.Sprintf("Error to create cache: %s", err.Error()))
+	return
+}
+
+// clicksSeries sum hourly counters in buckets of granularity size between "from" and "to".
+// Buckets without clicks are present with zero, so dashboards can plot a continuous line.
+func clicksSeries(hours map[string]int, from, to time.Time, granularity string) (series []model.LinkClicksPoint, err error) {
+	from = truncateTime(from.UTC(), granularity)
+	to = to.UTC()
+
+	index := map[time.Time]int{}
+	series = []model.LinkClicksPoint{}
+
+	for t := from; !t.After(to); t = nextTime(t, granularity) {
+		if len(series) == maxClicksSeriesPoints {
+			return nil, e.ErrLinkClicksTooManyPoints
+		}
+
+		index[t] = len(series)
+		series = append(series, model.LinkClicksPoint{Timestamp: t})
+	}
+
+	for hour, value := range hours {
+		t, err := time.Parse(layoutMetricCounter, hour)
+		if err != nil || t.Before(from) || t.After(to) {
+			continue
 		}
-	}()
 
-	lc.Total = total
+		if i, ok := index[truncateTime(t, granularity)]; ok {
+			series[i].Total += value
+		}
+	}
+
+	return
+}
+
+// truncateTime get the beginning of bucket where "t" is. Weeks start on Monday.
+func truncateTime(t time.Time, granularity string) time.Time {
+	year, month, day := t.Date()
+
+	switch granularity {
+	case granularityHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case granularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case granularityMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextTime get the beginning of next bucket after "t".
+func nextTime(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case granularityHour:
+		return t.Add(time.Hour)
+	case granularityWeek:
+		return t.AddDate(0, 0, 7)
+	case granularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// queryer is a database or a transaction.
+type queryer interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// linkColumns are columns read by scanLink, in the same order.
+const linkColumns = `id, user_id, created_at, updated_at, domain, keyword, url, title, active,
+	expires_at, max_clicks, fallback_url, password, deleted_at, redirect_type, forward_query, forward_path,
+	utm_source, utm_medium, utm_campaign, utm_term, utm_content, sticky_variant, starts_at, ends_at, schedule,
+	unavailable_url, COALESCE(folder_id, '')`
+
+// linkClicks is the total of clicks of a link, to read after linkColumns.
+const linkClicks = `(SELECT COALESCE(SUM(total), 0) FROM links_clicks WHERE link_id = links.id)`
+
+// sortColumns are the permitted sorts of links list and its SQL expression.
+var sortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"title":      "COALESCE(title, '')",
+	"keyword":    "keyword",
+	"clicks":     linkClicks,
+}
+
+// sortValue is the value of link in sort column, saved in cursors.
+func sortValue(link model.Link, sort string) string {
+	switch sort {
+	case "created_at":
+		return link.CreatedAt.Format(time.RFC3339Nano)
+	case "title":
+		return link.Title
+	case "keyword":
+		return link.Keyword
+	case "clicks":
+		return strconv.Itoa(link.Clicks.Total)
+	}
+	return ""
+}
+
+// searchQuery convert a free text in a tsquery matching all words by prefix,
+// like "go blog" to "go:* & blog:*". Empty when there are no words.
+func searchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// scanLink read a row from linkColumns and fill nullable fields.
+// Columns after linkColumns, if any, are read into "extra".
+func scanLink(row interface{ Scan(...any) error }, link *model.Link, extra ...any) (err error) {
+	schedule := sql.NullString{}
+
+	dest := []any{
+		&link.ID,
+		&link.UserID,
+		&link.CreatedAt,
+		&link.UpdatedAtNull,
+		&link.Domain,
+		&link.Keyword,
+		&link.URL,
+		&link.Title,
+		&link.Active,
+		&link.ExpiresAtNull,
+		&link.MaxClicks,
+		&link.FallbackURL,
+		&link.Password,
+		&link.DeletedAtNull,
+		&link.RedirectType,
+		&link.ForwardQuery,
+		&link.ForwardPath,
+		&link.Source,
+		&link.Medium,
+		&link.Campaign,
+		&link.Term,
+		&link.Content,
+		&link.StickyVariant,
+		&link.StartsAtNull,
+		&link.EndsAtNull,
+		&schedule,
+		&link.UnavailableURL,
+		&link.FolderID,
+	}
+
+	err = row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return
+	}
+
+	link.Protected = link.Password != ""
+
+	link.UpdatedAt = nil
+	if link.UpdatedAtNull.Valid {
+		link.UpdatedAt = &link.UpdatedAtNull.Time
+	}
+
+	link.ExpiresAt = nil
+	if link.ExpiresAtNull.Valid {
+		link.ExpiresAt = &link.ExpiresAtNull.Time
+	}
+
+	link.DeletedAt = nil
+	if link.DeletedAtNull.Valid {
+		link.DeletedAt = &link.DeletedAtNull.Time
+	}
+
+	link.StartsAt = nil
+	if link.StartsAtNull.Valid {
+		link.StartsAt = &link.StartsAtNull.Time
+	}
+
+	link.EndsAt = nil
+	if link.EndsAtNull.Valid {
+		link.EndsAt = &link.EndsAtNull.Time
+	}
+
+	link.Schedule = nil
+	if schedule.Valid {
+		link.Schedule = &model.LinkSchedule{}
+		err = json.Unmarshal([]byte(schedule.String), link.Schedule)
+	}
 	return
 }
 
@@ -481,7 +1489,7 @@ func setItemToCache(ctx context.Context, cache *redis.Client, key string, value
 func increaseCounter(ctx context.Context, cache *redis.Client, domain, keyword string) {
 	log := logger.Logger(ctx)
 
-	now := time.Now().Format("2006-01-02-15")
+	now := time.Now().UTC().Format(layoutMetricCounter)
 	log.Debug().Caller().Msg(fmt.Sprintf("Now: %s", now))
 
 	keyHash := fmt.Sprintf(keyMetricShortLink, domain, keyword)
@@ -495,4 +1503,72 @@ func increaseCounter(ctx context.Context, cache *redis.Client, domain, keyword s
 
 	counter, _ := stat.Result()
 	log.Debug().Caller().Msg(fmt.Sprintf("Counter per hour: %d", counter))
+
+	keyTotal := fmt.Sprintf(keyMetricTotal, domain, keyword)
+	if err := increaseTotal.Run(ctx, cache, []string{keyTotal}).Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+}
+
+// increaseTotal sum by 1 a running total, only if it exists. A new total is always counted from history.
+var increaseTotal = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCR", KEYS[1])
+end
+return 0
+`)
+
+// moveCounters rename metric hash of a link after its domain or keyword change.
+// A link without clicks in cache has no hash, so there is nothing to move.
+func moveCounters(ctx context.Context, cache *redis.Client, oldDomain, oldKeyword, domain, keyword string) error {
+	from := fmt.Sprintf(keyMetricShortLink, oldDomain, oldKeyword)
+	to := fmt.Sprintf(keyMetricShortLink, domain, keyword)
+
+	err := cache.Rename(ctx, from, to).Err()
+	if err != nil && strings.Contains(err.Error(), "no such key") {
+		return nil
+	}
+	return err
+}
+
+// addClick save a click event for link with domain and keyword combination.
+// The click is enriched with location from client IP, if found.
+func addClick(ctx context.Context, db *sql.DB, cache *redis.Client, domain, keyword string, click model.Click) {
+	log := logger.Logger(ctx)
+
+	// Keep going on error from location, a click without it still matters.
+	loc, err := location.Find(ctx, db, cache, click.IP)
+	if err != nil {
+		log.Warn().Caller().Msg(err.Error())
+	}
+
+	click.Country = loc.Country
+	click.State = loc.State
+	click.City = loc.City
+
+	query := `
+		INSERT INTO clicks(id, created_at, referer, user_agent, ip, language, country, state, city, link_id, variant_id)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, id, NULLIF($12, '') FROM links WHERE domain = $10 AND keyword = $11
+	`
+	log.Debug().Caller().Msg(query)
+
+	_, err = db.ExecContext(
+		ctx,
+		query,
+		ulid.Make().String(),
+		click.Timestamp,
+		click.Referer,
+		click.UserAgent,
+		click.IP,
+		click.Language,
+		click.Country,
+		click.State,
+		click.City,
+		domain,
+		keyword,
+		click.VariantID,
+	)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
 }
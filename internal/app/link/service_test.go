@@ -0,0 +1,152 @@
+package link
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+func TestTruncateTime(t *testing.T) {
+	// Wednesday.
+	at := time.Date(2024, 1, 3, 15, 42, 10, 0, time.UTC)
+
+	tests := []struct {
+		granularity string
+		t           time.Time
+		want        time.Time
+	}{
+		{granularityHour, at, time.Date(2024, 1, 3, 15, 0, 0, 0, time.UTC)},
+		{granularityDay, at, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{granularityWeek, at, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{granularityWeek, time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{granularityWeek, time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC), time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{granularityMonth, at, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"", at, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity+" "+tt.t.Format(time.RFC3339), func(t *testing.T) {
+			if got := truncateTime(tt.t, tt.granularity); !got.Equal(tt.want) {
+				t.Errorf("truncateTime() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClicksSeries(t *testing.T) {
+	day := func(d, h int) time.Time {
+		return time.Date(2024, 1, d, h, 0, 0, 0, time.UTC)
+	}
+
+	hours := map[string]int{
+		"2024-01-01-10": 2,
+		"2024-01-01-23": 3,
+		"2024-01-03-00": 5,
+		"2024-01-09-12": 7,
+		"invalid":       100,
+	}
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		granularity string
+		want        []model.LinkClicksPoint
+		wantErr     error
+	}{
+		{
+			name:        "by day with empty days",
+			from:        day(1, 0),
+			to:          day(3, 12),
+			granularity: granularityDay,
+			want: []model.LinkClicksPoint{
+				{Timestamp: day(1, 0), Total: 5},
+				{Timestamp: day(2, 0), Total: 0},
+				{Timestamp: day(3, 0), Total: 5},
+			},
+		},
+		{
+			name:        "by hour",
+			from:        day(1, 9),
+			to:          day(1, 11),
+			granularity: granularityHour,
+			want: []model.LinkClicksPoint{
+				{Timestamp: day(1, 9), Total: 0},
+				{Timestamp: day(1, 10), Total: 2},
+				{Timestamp: day(1, 11), Total: 0},
+			},
+		},
+		{
+			name:        "by week",
+			from:        day(3, 0),
+			to:          day(10, 0),
+			granularity: granularityWeek,
+			want: []model.LinkClicksPoint{
+				{Timestamp: day(1, 0), Total: 10},
+				{Timestamp: day(8, 0), Total: 7},
+			},
+		},
+		{
+			name:        "other timezones are UTC",
+			from:        time.Date(2024, 1, 1, 7, 0, 0, 0, time.FixedZone("BRT", -3*60*60)),
+			to:          time.Date(2024, 1, 1, 7, 0, 0, 0, time.FixedZone("BRT", -3*60*60)),
+			granularity: granularityHour,
+			want: []model.LinkClicksPoint{
+				{Timestamp: day(1, 10), Total: 2},
+			},
+		},
+		{
+			name:        "too many points",
+			from:        day(1, 0),
+			to:          day(1, 0).Add(maxClicksSeriesPoints * time.Hour),
+			granularity: granularityHour,
+			wantErr:     e.ErrLinkClicksTooManyPoints,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := clicksSeries(hours, tt.from, tt.to, tt.granularity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("clicksSeries() error = %v; want %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("clicksSeries() = %v; want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if !got[i].Timestamp.Equal(tt.want[i].Timestamp) || got[i].Total != tt.want[i].Total {
+					t.Errorf("clicksSeries()[%d] = %+v; want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"go", "go:*"},
+		{"Go Blog", "go:* & blog:*"},
+		{"  go   blog ", "go:* & blog:*"},
+		{"go-blog.com", "go:* & blog:* & com:*"},
+		{"it's (a) test!", "it:* & s:* & a:* & test:*"},
+		{"':* | !", ""},
+		{"café 2024", "café:* & 2024:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := searchQuery(tt.text); got != tt.want {
+				t.Errorf("searchQuery(%q) = %q; want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
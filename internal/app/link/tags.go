@@ -0,0 +1,151 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// SetTags replace all tags from a link. Without tags, link is untagged.
+func (s service) SetTags(c *gin.Context, payload tagsRequest) (tags []model.Tag, err error) {
+	return s.changeTags(c, payload, true)
+}
+
+// AddTags attach more tags to a link, keeping the current ones.
+func (s service) AddTags(c *gin.Context, payload tagsRequest) (tags []model.Tag, err error) {
+	return s.changeTags(c, payload, false)
+}
+
+// RemoveTag detach a tag from a link.
+func (s service) RemoveTag(c *gin.Context, payload tagRemoveRequest) (err error) {
+	log := logger.Logger(c)
+
+	query := `DELETE FROM link_tags WHERE link_id = $1 AND tag_id = $2
+		AND link_id IN (SELECT id FROM links WHERE user_id = $3)`
+
+	result, err := s.db.ExecContext(c, query, payload.LinkID, payload.TagID, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return e.ErrTagNotFound
+	}
+	return
+}
+
+// changeTags attach tags to a link in one transaction. Link and tags must be from the same user.
+func (s service) changeTags(c *gin.Context, payload tagsRequest, replace bool) (tags []model.Tag, err error) {
+	log := logger.Logger(c)
+
+	tx, err := s.db.BeginTx(c, &sql.TxOptions{})
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	linkID := ""
+	err = tx.QueryRowContext(c, "SELECT id FROM links WHERE id = $1 AND user_id = $2", payload.LinkID, payload.WhoID).
+		Scan(&linkID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tags, e.ErrLinkNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	total := 0
+	err = tx.QueryRowContext(c, "SELECT COUNT(0) FROM tags WHERE id = ANY($1) AND user_id = $2",
+		pq.Array(payload.TagIDs), payload.WhoID).Scan(&total)
+
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	if total != len(payload.TagIDs) {
+		return tags, e.ErrTagNotFound
+	}
+
+	if replace {
+		if _, err = tx.ExecContext(c, "DELETE FROM link_tags WHERE link_id = $1", linkID); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return tags, e.ErrInternalServerError
+		}
+	}
+
+	query := `INSERT INTO link_tags(link_id, tag_id) SELECT $1, UNNEST($2::VARCHAR[])
+		ON CONFLICT DO NOTHING`
+
+	if _, err = tx.ExecContext(c, query, linkID, pq.Array(payload.TagIDs)); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	byLink, err := s.linkTags(c, []string{linkID})
+	if err != nil {
+		return
+	}
+
+	tags = byLink[linkID]
+	if tags == nil {
+		tags = []model.Tag{}
+	}
+	return
+}
+
+// linkTags get tags from many links with only one query.
+func (s service) linkTags(ctx context.Context, linkIDs []string) (tags map[string][]model.Tag, err error) {
+	log := logger.Logger(ctx)
+	tags = map[string][]model.Tag{}
+
+	if len(linkIDs) == 0 {
+		return
+	}
+
+	query := `SELECT lt.link_id, t.id, t.created_at, t.name, t.description FROM link_tags lt
+		INNER JOIN tags t ON t.id = lt.tag_id
+		WHERE lt.link_id = ANY($1) ORDER BY t.name ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(linkIDs))
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		linkID := ""
+		tag := model.Tag{}
+
+		if err = rows.Scan(&linkID, &tag.ID, &tag.CreatedAt, &tag.Name, &tag.Description); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return tags, e.ErrInternalServerError
+		}
+
+		tags[linkID] = append(tags[linkID], tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,186 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/location"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+	"github.com/wvoliveira/corgi/internal/pkg/useragent"
+)
+
+const maxTargets = 20
+
+// countryCode is an ISO 3166-1 alpha-2 code, like BR.
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// targetURL is the destination of first rule matching visitor, if any.
+// Country is only resolved from IP when a rule needs it.
+func (s service) targetURL(ctx context.Context, link model.Link, click model.Click) (string, bool) {
+	if len(link.Targets) == 0 {
+		return "", false
+	}
+
+	ua := useragent.Parse(click.UserAgent)
+	country, resolved := "", false
+
+	for _, t := range link.Targets {
+		if !matchField(t.Device, ua.Device) || !matchField(t.OS, ua.OS) || !matchField(t.Browser, ua.Browser) {
+			continue
+		}
+
+		if t.Country != "" && !resolved {
+			country, resolved = s.country(ctx, click.IP), true
+		}
+
+		if matchField(t.Country, country) {
+			return t.URL, true
+		}
+	}
+	return "", false
+}
+
+// country of an IP address, or empty if unknown.
+func (s service) country(ctx context.Context, ip string) string {
+	log := logger.Logger(ctx)
+
+	loc, err := location.Find(ctx, s.db, s.cache, ip)
+	if err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return ""
+	}
+	return strings.ToUpper(loc.Country)
+}
+
+// matchField check a rule field. Empty rule match anything.
+func matchField(rule, value string) bool {
+	return rule == "" || rule == value
+}
+
+// normalizeTargets trim and lower rules, so "iOS" and "ios" are the same.
+// Countries are upper, like in location databases.
+func normalizeTargets(targets []model.LinkTarget) []model.LinkTarget {
+	normalized := make([]model.LinkTarget, 0, len(targets))
+
+	for _, t := range targets {
+		normalized = append(normalized, model.LinkTarget{
+			Device:  strings.ToLower(strings.TrimSpace(t.Device)),
+			OS:      strings.ToLower(strings.TrimSpace(t.OS)),
+			Browser: strings.ToLower(strings.TrimSpace(t.Browser)),
+			Country: strings.ToUpper(strings.TrimSpace(t.Country)),
+			URL:     strings.TrimSpace(t.URL),
+		})
+	}
+	return normalized
+}
+
+// checkTargets validate rules: known values, at least one condition and a valid URL in each.
+func checkTargets(targets []model.LinkTarget) (err error) {
+	if len(targets) > maxTargets {
+		return e.ErrLinkInvalidTarget
+	}
+
+	for _, t := range targets {
+		if t.Device == "" && t.OS == "" && t.Browser == "" && t.Country == "" {
+			return e.ErrLinkInvalidTarget
+		}
+
+		if !known(t.Device, useragent.Devices) || !known(t.OS, useragent.Systems) ||
+			!known(t.Browser, useragent.Browsers) {
+			return e.ErrLinkInvalidTarget
+		}
+
+		if t.Country != "" && !countryCode.MatchString(t.Country) {
+			return e.ErrLinkInvalidTarget
+		}
+
+		if err = validation.Validate(t.URL, validation.Required, is.URL); err != nil {
+			return e.ErrLinkInvalidTarget
+		}
+	}
+	return nil
+}
+
+// known check if value is empty or one of permitted values.
+func known(value string, permitted []string) bool {
+	if value == "" {
+		return true
+	}
+
+	for _, p := range permitted {
+		if value == p {
+			return true
+		}
+	}
+	return false
+}
+
+// targetsValue is how rules are saved in revisions.
+func targetsValue(targets []model.LinkTarget) string {
+	if len(targets) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal(targets)
+	return string(b)
+}
+
+// linkTargets get rules from a link, in order.
+func linkTargets(ctx context.Context, q queryer, linkID string) (targets []model.LinkTarget, err error) {
+	log := logger.Logger(ctx)
+
+	query := "SELECT device, os, browser, country, url FROM link_targets WHERE link_id = $1 ORDER BY position ASC"
+
+	rows, err := q.QueryContext(ctx, query, linkID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return targets, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		t := model.LinkTarget{}
+
+		if err = rows.Scan(&t.Device, &t.OS, &t.Browser, &t.Country, &t.URL); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return targets, e.ErrInternalServerError
+		}
+
+		targets = append(targets, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return targets, e.ErrInternalServerError
+	}
+	return
+}
+
+// setTargets replace all rules from a link.
+func setTargets(ctx context.Context, q queryer, linkID string, targets []model.LinkTarget) (err error) {
+	log := logger.Logger(ctx)
+
+	if _, err = q.ExecContext(ctx, "DELETE FROM link_targets WHERE link_id = $1", linkID); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	query := `INSERT INTO link_targets(link_id, position, device, os, browser, country, url)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+
+	for i, t := range targets {
+		_, err = q.ExecContext(ctx, query, linkID, i, t.Device, t.OS, t.Browser, t.Country, t.URL)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+	}
+	return
+}
@@ -0,0 +1,62 @@
+package link
+
+import (
+	"errors"
+	"testing"
+
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+func TestCheckTargets(t *testing.T) {
+	url := "https://example.com"
+
+	tooMany := make([]model.LinkTarget, maxTargets+1)
+	for i := range tooMany {
+		tooMany[i] = model.LinkTarget{Device: "mobile", URL: url}
+	}
+
+	tests := []struct {
+		name    string
+		targets []model.LinkTarget
+		wantErr bool
+	}{
+		{"no targets", nil, false},
+		{"device", []model.LinkTarget{{Device: "mobile", URL: url}}, false},
+		{"all fields", []model.LinkTarget{{Device: "desktop", OS: "linux", Browser: "firefox", URL: url}}, false},
+		{"without conditions", []model.LinkTarget{{URL: url}}, true},
+		{"unknown device", []model.LinkTarget{{Device: "watch", URL: url}}, true},
+		{"unknown os", []model.LinkTarget{{OS: "beos", URL: url}}, true},
+		{"unknown browser", []model.LinkTarget{{Browser: "lynx", URL: url}}, true},
+		{"not normalized", []model.LinkTarget{{OS: "iOS", URL: url}}, true},
+		{"without URL", []model.LinkTarget{{Device: "mobile"}}, true},
+		{"invalid URL", []model.LinkTarget{{Device: "mobile", URL: "not a url"}}, true},
+		{"too many", tooMany, true},
+		{"country", []model.LinkTarget{{Country: "BR", URL: url}}, false},
+		{"country and device", []model.LinkTarget{{Device: "mobile", Country: "US", URL: url}}, false},
+		{"lower country", []model.LinkTarget{{Country: "br", URL: url}}, true},
+		{"country name", []model.LinkTarget{{Country: "Brazil", URL: url}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTargets(tt.targets)
+			if tt.wantErr && !errors.Is(err, e.ErrLinkInvalidTarget) {
+				t.Errorf("checkTargets() = %v; want %v", err, e.ErrLinkInvalidTarget)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkTargets() = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeTargets(t *testing.T) {
+	got := normalizeTargets([]model.LinkTarget{{Device: " Mobile ", OS: "iOS", Browser: "Safari", Country: " br", URL: " https://example.com "}})
+	want := model.LinkTarget{Device: "mobile", OS: "ios", Browser: "safari", Country: "BR", URL: "https://example.com"}
+
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("normalizeTargets() = %+v; want [%+v]", got, want)
+	}
+}
@@ -1,7 +1,12 @@
 package link
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
@@ -12,15 +17,33 @@ import (
 // NewHTTP create a new http endpoint for link service.
 // This is a principal service, and it has a root router to redirect
 // links by domain and keyword combination.
-func (s service) NewHTTP(root *gin.Engine, rg *gin.RouterGroup) {
+func (s service) NewHTTP(root *gin.Engine, rg *gin.RouterGroup, fallback gin.HandlerFunc) {
 	root.GET("/:keyword", s.HTTPRedirect)
+	root.POST("/:keyword", s.HTTPUnlock)
+
+	// Paths after keyword are only for links with "forward_path".
+	// Anything else goes to fallback, like files from web interface.
+	root.GET("/:keyword/*path", s.forwardPath(s.HTTPRedirect, fallback))
+	root.POST("/:keyword/*path", s.forwardPath(s.HTTPUnlock, fallback))
 
 	r := rg.Group("/links")
 	r.Use(middleware.Checks())
 
 	r.POST("", s.HTTPAdd)
+	r.POST("/batch", s.HTTPAddBatch)
+	r.PATCH("/batch", s.HTTPUpdateBatch)
+	r.DELETE("/batch", s.HTTPDeleteBatch)
+	r.GET("/export", s.HTTPExport)
+	r.POST("/import", s.HTTPImport)
 	r.GET("", s.HTTPFindAll)
 	r.GET("/:id", s.HTTPFindByID)
+	r.GET("/:id/qr", s.HTTPQRCode)
+	r.PUT("/:id/tags", s.HTTPSetTags)
+	r.POST("/:id/tags", s.HTTPAddTags)
+	r.DELETE("/:id/tags/:tag_id", s.HTTPRemoveTag)
+	r.GET("/trash", s.HTTPTrash)
+	r.GET("/:id/history", s.HTTPHistory)
+	r.POST("/:id/restore", s.HTTPRestore)
 	r.PATCH("/:id", s.HTTPUpdate)
 	r.DELETE("/:id", s.HTTPDelete)
 	r.GET("/keyword/:keyword", s.HTTPFindFullURL)
@@ -29,20 +52,128 @@ func (s service) NewHTTP(root *gin.Engine, rg *gin.RouterGroup) {
 }
 
 func (s service) HTTPRedirect(ctx *gin.Context) {
+	// Gin can't route "/:keyword.qr", so public QR codes come here too.
+	if strings.HasSuffix(ctx.Param("keyword"), qrSuffix) {
+		s.HTTPQRCode(ctx)
+		return
+	}
+
 	d, err := decodeFindByKeyword(ctx)
 	if err != nil {
 		e.EncodeError(ctx, err)
 		return
 	}
 
-	link, err := s.FindRedirectURL(ctx, d.Domain, d.Keyword)
+	link, err := s.FindRedirectURL(ctx, d)
+	if errors.Is(err, e.ErrLinkExpired) && link.FallbackURL != "" {
+		encodeFallback(ctx, link)
+		return
+	}
+
+	if errors.Is(err, e.ErrLinkUnavailable) {
+		encodeUnavailable(ctx, link)
+		return
+	}
+
+	if errors.Is(err, e.ErrLinkPasswordRequired) {
+		encodeUnlock(ctx, d.Domain, d.Keyword, err)
+		return
+	}
+
 	if err != nil {
 		e.EncodeError(ctx, err)
 		return
 	}
 
+	d.Click.VariantID = link.VariantID
+	encodeVariant(ctx, link)
+
+	// Save click event in background process.
+	// Copy context because gin reuse it after the handler returns.
+	go addClick(ctx.Copy(), s.db, s.cache, d.Domain, d.Keyword, d.Click)
+
+	// Browsers must not keep redirects that change, or visits would skip limits and counters.
+	if !cacheable(link) {
+		ctx.Header("Cache-Control", "no-store")
+	}
+
+	link.URL = destinationURL(link, d.Path, d.Query)
+	encodeRedirectLink(ctx, link)
+}
+
+// forwardPath call "next" when link from keyword forward paths, otherwise "fallback".
+// Files of web interface, like "/_next/app.js", go to fallback without looking for a link.
+func (s service) forwardPath(next, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if reservedKeyword(c.Param("keyword")) {
+			fallback(c)
+			return
+		}
+
+		if strings.Trim(c.Param("path"), "/") == "" {
+			next(c)
+			return
+		}
+
+		link, err := s.findByKeyword(c, strings.ToLower(c.Request.Host), c.Param("keyword"))
+		if err != nil || !link.ForwardPath {
+			fallback(c)
+			return
+		}
+
+		next(c)
+	}
+}
+
+// HTTPUnlock receive password of a protected link from form or JSON.
+// Browsers are redirected, another clients get the destination URL.
+func (s service) HTTPUnlock(ctx *gin.Context) {
+	d, err := decodeUnlock(ctx)
+	if errors.Is(err, e.ErrLinkPasswordRequired) {
+		encodeUnlock(ctx, d.Domain, d.Keyword, err)
+		return
+	}
+
+	if err != nil {
+		e.EncodeError(ctx, err)
+		return
+	}
+
+	link, err := s.Unlock(ctx, d)
+	if errors.Is(err, e.ErrLinkExpired) && link.FallbackURL != "" {
+		encodeFallback(ctx, link)
+		return
+	}
+
+	if errors.Is(err, e.ErrLinkUnavailable) {
+		encodeUnavailable(ctx, link)
+		return
+	}
+
+	if errors.Is(err, e.ErrLinkPasswordWrong) || errors.Is(err, e.ErrLinkPasswordAttempts) {
+		encodeUnlock(ctx, d.Domain, d.Keyword, err)
+		return
+	}
+
+	if err != nil {
+		e.EncodeError(ctx, err)
+		return
+	}
+
+	d.Click.VariantID = link.VariantID
+	encodeVariant(ctx, link)
+	go addClick(ctx.Copy(), s.db, s.cache, d.Domain, d.Keyword, d.Click)
+
+	link.URL = destinationURL(link, d.Path, d.Query)
 	url := encodeRedirect(link)
-	ctx.Redirect(301, url.URL)
+	ctx.Header("Cache-Control", "no-store")
+
+	if !wantsHTML(ctx) {
+		response.Default(ctx, url, "", http.StatusOK)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, url.URL)
 }
 
 func (s service) HTTPAdd(ctx *gin.Context) {
@@ -84,20 +215,21 @@ func (s service) HTTPFindAll(ctx *gin.Context) {
 		return
 	}
 
-	total, pages, links, err := s.FindAll(ctx, payload)
+	total, pages, links, next, err := s.FindAll(ctx, payload)
 	if err != nil {
 		e.EncodeError(ctx, err)
 		return
 	}
 
 	sr := findAllResponse{
-		Links: links,
-		Limit: payload.Limit,
-		Page:  payload.Page,
-		Sort:  payload.Sort,
-		Total: total,
-		Pages: pages,
-		Err:   err,
+		Links:      links,
+		Limit:      payload.Limit,
+		Page:       payload.Page,
+		Sort:       payload.Sort,
+		Total:      total,
+		Pages:      pages,
+		NextCursor: next,
+		Err:        err,
 	}
 
 	response.Default(ctx, sr, "", http.StatusOK)
@@ -110,13 +242,13 @@ func (s service) HTTPUpdate(ctx *gin.Context) {
 		return
 	}
 
-	err = s.Update(ctx, payload)
+	link, err := s.Update(ctx, payload)
 	if err != nil {
 		e.EncodeError(ctx, err)
 		return
 	}
 
-	response.Default(ctx, nil, "", http.StatusOK)
+	response.Default(ctx, link, "", http.StatusOK)
 }
 
 func (s service) HTTPDelete(ctx *gin.Context) {
@@ -168,3 +300,229 @@ func (s service) HTTPClicks(c *gin.Context) {
 	//url := encodeFindByKeyword(linkClicks)
 	response.Default(c, linkClicks, "", http.StatusOK)
 }
+
+func (s service) HTTPAddBatch(c *gin.Context) {
+	payload, err := decodeBatchAdd(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	results, err := s.AddBatch(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeBatch(results), "", http.StatusOK)
+}
+
+func (s service) HTTPUpdateBatch(c *gin.Context) {
+	payload, err := decodeBatchUpdate(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	results, err := s.UpdateBatch(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeBatch(results), "", http.StatusOK)
+}
+
+func (s service) HTTPDeleteBatch(c *gin.Context) {
+	payload, err := decodeBatchDelete(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	results, err := s.DeleteBatch(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeBatch(results), "", http.StatusOK)
+}
+
+// HTTPExport stream links as a file. Errors after the first row can't change response anymore.
+func (s service) HTTPExport(c *gin.Context) {
+	payload, err := decodeExport(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if payload.Format == formatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	filename := fmt.Sprintf("links-%s.%s", time.Now().Format("2006-01-02"), payload.Format)
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	err = s.Export(c, payload, c.Writer)
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		e.EncodeError(c, err)
+	}
+}
+
+func (s service) HTTPImport(c *gin.Context) {
+	payload, err := decodeImport(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	if closer, ok := payload.File.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	results, err := s.Import(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeBatch(results), "", http.StatusOK)
+}
+
+// HTTPQRCode render QR code from "/api/links/:id/qr" or public "/:keyword.qr".
+func (s service) HTTPQRCode(c *gin.Context) {
+	payload, err := decodeQR(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	image, err := s.QRCode(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	// Only QR codes from public path can be in shared caches, API ones are from links of user.
+	if payload.Keyword != "" {
+		c.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		c.Header("Cache-Control", "private, max-age=86400")
+	}
+
+	c.Data(http.StatusOK, payload.Options.ContentType(), image)
+}
+
+func (s service) HTTPSetTags(c *gin.Context) {
+	payload, err := decodeTags(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tags, err := s.SetTags(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, tagsResponse{Tags: tags}, "", http.StatusOK)
+}
+
+func (s service) HTTPAddTags(c *gin.Context) {
+	payload, err := decodeTags(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tags, err := s.AddTags(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, tagsResponse{Tags: tags}, "", http.StatusOK)
+}
+
+func (s service) HTTPRemoveTag(c *gin.Context) {
+	payload, err := decodeTagRemove(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.RemoveTag(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
+
+func (s service) HTTPHistory(c *gin.Context) {
+	payload, err := decodeFindByID(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	revisions, err := s.History(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, historyResponse{Revisions: revisions}, "", http.StatusOK)
+}
+
+func (s service) HTTPTrash(ctx *gin.Context) {
+	payload, err := decodeFindAll(ctx)
+	if err != nil {
+		e.EncodeError(ctx, err)
+		return
+	}
+
+	payload.Trash = true
+
+	total, pages, links, next, err := s.FindAll(ctx, payload)
+	if err != nil {
+		e.EncodeError(ctx, err)
+		return
+	}
+
+	sr := findAllResponse{
+		Links:      links,
+		Limit:      payload.Limit,
+		Page:       payload.Page,
+		Sort:       payload.Sort,
+		Total:      total,
+		Pages:      pages,
+		NextCursor: next,
+	}
+
+	response.Default(ctx, sr, "", http.StatusOK)
+}
+
+func (s service) HTTPRestore(c *gin.Context) {
+	payload, err := decodeFindByID(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	link, err := s.Restore(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, link, "", http.StatusOK)
+}
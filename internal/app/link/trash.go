@@ -0,0 +1,136 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+// Restore take a link back from trash, active again.
+func (s service) Restore(c *gin.Context, payload findByIDRequest) (link model.Link, err error) {
+	log := logger.Logger(c)
+
+	tx, err := s.db.BeginTx(c, &sql.TxOptions{})
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := `UPDATE links SET active = true, deleted_at = NULL, updated_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NOT NULL
+		RETURNING ` + linkColumns
+	log.Debug().Caller().Msg(query)
+
+	err = scanLink(tx.QueryRowContext(c, query, time.Now(), payload.LinkID, payload.WhoID), &link)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return link, e.ErrLinkNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
+
+	revisions := diffLink(model.Link{Active: "false"}, model.Link{Active: link.Active})
+	if err = insertRevisions(c, tx, link.ID, payload.WhoID, revisions); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return link, e.ErrInternalServerError
+	}
+
+	// Keyword may be cached as not found while in trash.
+	s.uncache(c, link)
+
+	tags, err := s.linkTags(c, []string{link.ID})
+	if err != nil {
+		return
+	}
+
+	link.Tags = tags[link.ID]
+	return
+}
+
+// PurgeTrash delete forever links in trash for more than "retention", with their clicks.
+// After that, their domain and keyword can be used again.
+func (s service) PurgeTrash(ctx context.Context, retention time.Duration) (total int, err error) {
+	log := logger.Logger(ctx)
+	before := time.Now().Add(-retention)
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, e.ErrInternalServerError
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Only links_clicks doesn't delete in cascade.
+	query := "DELETE FROM links_clicks WHERE link_id IN (SELECT id FROM links WHERE deleted_at < $1)"
+	if _, err = tx.ExecContext(ctx, query, before); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, e.ErrInternalServerError
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM links WHERE deleted_at < $1 RETURNING domain, keyword", before)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, e.ErrInternalServerError
+	}
+
+	keys := []string{}
+	for rows.Next() {
+		domain, keyword := "", ""
+		if err = rows.Scan(&domain, &keyword); err != nil {
+			_ = rows.Close()
+			log.Error().Caller().Msg(err.Error())
+			return total, e.ErrInternalServerError
+		}
+
+		keys = append(keys,
+			fmt.Sprintf(keyCacheShortLink, domain, keyword),
+			fmt.Sprintf(keyCacheShortLinkMetricCounterHours, domain, keyword),
+			fmt.Sprintf(keyMetricShortLink, domain, keyword),
+			fmt.Sprintf(keyMetricTotal, domain, keyword),
+		)
+		total++
+	}
+
+	if err = rows.Close(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, e.ErrInternalServerError
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return total, e.ErrInternalServerError
+	}
+
+	// Counters from purged links must not be synced again, neither used by a new link with same keyword.
+	// Keep going on error from cache, links are already deleted.
+	if len(keys) > 0 {
+		if err := s.cache.Del(ctx, keys...).Err(); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package link
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/response"
+)
+
+// unlockPage is served to browsers when a protected link is opened.
+// Form is posted to same path, so it works with any domain and keyword.
+var unlockPage = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>Protected link</title>
+</head>
+<body>
+	<h1>This link is protected</h1>
+	<p>Enter the password to open {{.Domain}}/{{.Keyword}}.</p>
+	{{if .Message}}<p role="alert">{{.Message}}</p>{{end}}
+	<form method="post">
+		<input type="password" name="password" autofocus required>
+		<button type="submit">Open</button>
+	</form>
+</body>
+</html>
+`))
+
+type unlockChallenge struct {
+	Domain    string `json:"domain"`
+	Keyword   string `json:"keyword"`
+	Protected bool   `json:"protected"`
+	Message   string `json:"-"`
+}
+
+// wantsHTML check if client is a browser. Anything else get a JSON challenge.
+func wantsHTML(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+}
+
+// encodeUnlock ask for password of a protected link, with a HTML form or JSON.
+func encodeUnlock(c *gin.Context, domain, keyword string, err error) {
+	log := logger.Logger(c)
+
+	challenge := unlockChallenge{
+		Domain:    domain,
+		Keyword:   keyword,
+		Protected: true,
+	}
+
+	status := http.StatusUnauthorized
+	if err == e.ErrLinkPasswordAttempts {
+		status = http.StatusTooManyRequests
+	}
+
+	// Browser must not cache the form or the redirect after it.
+	c.Header("Cache-Control", "no-store")
+
+	if !wantsHTML(c) {
+		response.Default(c, challenge, err.Error(), status)
+		return
+	}
+
+	if err != e.ErrLinkPasswordRequired {
+		challenge.Message = err.Error()
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+
+	if err := unlockPage.Execute(c.Writer, challenge); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+}
@@ -1,13 +1,16 @@
 package link
 
 import (
+	"io/fs"
 	"sort"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/web"
 )
 
 // TODO: put theses keywords in database, so, we can update in real time.
@@ -15,7 +18,7 @@ import (
 var blockedKeywords = []string{"crash", "attack", "terrorist", "suicide", "nazi", "killed", "porn", "explosion",
 	"rape", "death", "isis", "shooting", "bomb", "dead", "murder", "terror", "kill", "sex", "massacre", "gun"}
 
-func checkLink(domain, keyword, url string) (err error) {
+func checkLink(keyword, url string) (err error) {
 	sort.Strings(blockedKeywords)
 	index := sort.SearchStrings(blockedKeywords, keyword)
 
@@ -23,6 +26,10 @@ func checkLink(domain, keyword, url string) (err error) {
 		return e.ErrLinkKeywordNotPermitted
 	}
 
+	if reservedKeyword(keyword) {
+		return e.ErrLinkKeywordNotPermitted
+	}
+
 	err = validation.Validate(url,
 		validation.Required,
 		is.URL,
@@ -32,12 +39,92 @@ func checkLink(domain, keyword, url string) (err error) {
 		log.Warn().Caller().Msg(err.Error())
 		return e.ErrLinkInvalidURL
 	}
+	return nil
+}
+
+// reservedKeyword check if keyword is the API or a top-level path of web interface, like "_next" or "images".
+// Links with them would take files of web interface, mainly with "forward_path".
+func reservedKeyword(keyword string) bool {
+	if keyword == "" {
+		return false
+	}
+
+	if keyword == "api" {
+		return true
+	}
+
+	_, err := fs.Stat(web.DistFS, keyword)
+	return err == nil
+}
+
+// publicDomain check if domain is the default or an alternative one, that everyone can use.
+func publicDomain(domain string) bool {
+	if domain == viper.GetString("DOMAIN_DEFAULT") {
+		return true
+	}
+
+	for _, alternative := range viper.GetStringSlice("DOMAIN_ALTERNATIVES") {
+		if domain == alternative {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpiration validate optional expiration fields of a link.
+func checkExpiration(expiresAt *time.Time, maxClicks int, fallbackURL string) (err error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return e.ErrLinkInvalidExpiration
+	}
+
+	if maxClicks < 0 {
+		return e.ErrLinkInvalidMaxClicks
+	}
 
-	domainDefault := viper.GetString("domain_default")
+	if fallbackURL == "" {
+		return nil
+	}
+
+	err = validation.Validate(fallbackURL, is.URL)
+	if err != nil {
+		log.Warn().Caller().Msg(err.Error())
+		return e.ErrLinkInvalidFallbackURL
+	}
+	return nil
+}
 
-	if domain == domainDefault {
+// checkPassword validate an optional link password. Bcrypt use only the first 72 bytes.
+func checkPassword(password string) (err error) {
+	if password == "" {
 		return nil
 	}
 
-	return e.ErrLinkInvalidDomain
+	if len(password) < 4 || len(password) > 72 {
+		return e.ErrLinkInvalidPassword
+	}
+	return nil
+}
+
+// checkBatch validate size of batch requests and imports. Anonymous users can't use them.
+func checkBatch(whoID string, total, max int) (err error) {
+	if whoID == "0" {
+		return e.ErrUnauthorized
+	}
+
+	if total == 0 || total > max {
+		return e.ErrLinkBatchSize
+	}
+	return nil
+}
+
+// checkRedirectType validate an optional redirect type. Empty is the system default.
+func checkRedirectType(redirectType string) error {
+	if redirectType == "" {
+		return nil
+	}
+
+	if _, ok := redirectStatus[redirectType]; !ok {
+		return e.ErrLinkInvalidRedirectType
+	}
+	return nil
 }
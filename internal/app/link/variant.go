@@ -0,0 +1,238 @@
+package link
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	maxVariants      = 10
+	maxVariantWeight = 1000
+
+	// Cookie with variant of a visitor, scoped to path of keyword.
+	variantCookie    = "corgi_variant"
+	variantCookieAge = 30 * 24 * time.Hour
+)
+
+// destination set URL of link to where this visitor goes.
+// Targeting rules come first, then a variant, if link has them.
+func (s service) destination(ctx context.Context, link model.Link, click model.Click, variantID string) model.Link {
+	if url, ok := s.targetURL(ctx, link, click); ok {
+		link.URL = url
+		return link
+	}
+
+	if v, ok := chooseVariant(link, variantID); ok {
+		link.URL = v.URL
+		link.VariantID = v.ID
+	}
+	return link
+}
+
+// chooseVariant pick a variant by weight. With sticky variants, the one from visitor is kept while it exists.
+func chooseVariant(link model.Link, variantID string) (variant model.LinkVariant, ok bool) {
+	if len(link.Variants) == 0 {
+		return
+	}
+
+	total := 0
+	for _, v := range link.Variants {
+		if link.StickyVariant && v.ID == variantID {
+			return v, true
+		}
+		total += v.Weight
+	}
+
+	if total <= 0 {
+		return link.Variants[0], true
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(total)))
+	if err != nil {
+		return link.Variants[0], true
+	}
+
+	r := int(n.Int64())
+	for _, v := range link.Variants {
+		if r < v.Weight {
+			return v, true
+		}
+		r -= v.Weight
+	}
+	return link.Variants[len(link.Variants)-1], true
+}
+
+// encodeVariant keep variant of visitor in a cookie, when link is sticky.
+// Browsers must not cache a redirect that changes in each visit.
+func encodeVariant(c *gin.Context, link model.Link) {
+	if link.VariantID == "" {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+
+	if link.StickyVariant {
+		c.SetCookie(variantCookie, link.VariantID, int(variantCookieAge.Seconds()), "/"+link.Keyword, "",
+			c.Request.TLS != nil, true)
+	}
+}
+
+// normalizeVariants trim URLs and give IDs to new variants. Variants from "old" keep their IDs,
+// so their clicks are still compared after a change.
+func normalizeVariants(variants, old []model.LinkVariant) []model.LinkVariant {
+	normalized := make([]model.LinkVariant, 0, len(variants))
+
+	for _, v := range variants {
+		id := ""
+		for _, o := range old {
+			if v.ID != "" && v.ID == o.ID {
+				id = o.ID
+			}
+		}
+
+		if id == "" {
+			id = ulid.Make().String()
+		}
+
+		if v.Weight == 0 {
+			v.Weight = 1
+		}
+
+		normalized = append(normalized, model.LinkVariant{ID: id, URL: strings.TrimSpace(v.URL), Weight: v.Weight})
+	}
+	return normalized
+}
+
+// checkVariants validate URL and weight of each variant.
+func checkVariants(variants []model.LinkVariant) (err error) {
+	if len(variants) > maxVariants {
+		return e.ErrLinkInvalidVariant
+	}
+
+	for _, v := range variants {
+		if v.Weight < 1 || v.Weight > maxVariantWeight {
+			return e.ErrLinkInvalidVariant
+		}
+
+		if err = validation.Validate(v.URL, validation.Required, is.URL); err != nil {
+			return e.ErrLinkInvalidVariant
+		}
+	}
+	return nil
+}
+
+// variantsValue is how variants are saved in revisions.
+func variantsValue(variants []model.LinkVariant) string {
+	if len(variants) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal(variants)
+	return string(b)
+}
+
+// linkVariants get variants from a link, in order.
+func linkVariants(ctx context.Context, q queryer, linkID string) (variants []model.LinkVariant, err error) {
+	log := logger.Logger(ctx)
+
+	query := "SELECT id, url, weight FROM link_variants WHERE link_id = $1 ORDER BY position ASC"
+
+	rows, err := q.QueryContext(ctx, query, linkID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return variants, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		v := model.LinkVariant{}
+
+		if err = rows.Scan(&v.ID, &v.URL, &v.Weight); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return variants, e.ErrInternalServerError
+		}
+
+		variants = append(variants, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return variants, e.ErrInternalServerError
+	}
+	return
+}
+
+// setVariants replace variants from a link. Removed variants keep their clicks, without variant.
+func setVariants(ctx context.Context, q queryer, linkID string, variants []model.LinkVariant) (err error) {
+	log := logger.Logger(ctx)
+
+	ids := []string{}
+	for _, v := range variants {
+		ids = append(ids, v.ID)
+	}
+
+	query := "DELETE FROM link_variants WHERE link_id = $1 AND NOT (id = ANY($2))"
+	if _, err = q.ExecContext(ctx, query, linkID, pq.Array(ids)); err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	query = `INSERT INTO link_variants(id, link_id, position, url, weight) VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET position = EXCLUDED.position, url = EXCLUDED.url, weight = EXCLUDED.weight`
+
+	for i, v := range variants {
+		if _, err = q.ExecContext(ctx, query, v.ID, linkID, i, v.URL, v.Weight); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return e.ErrInternalServerError
+		}
+	}
+	return
+}
+
+// variantClicks count clicks of each variant from a link in a period.
+func (s service) variantClicks(ctx context.Context, domain, keyword string, from, to time.Time) (
+	variants []model.LinkVariantClicks, err error) {
+	log := logger.Logger(ctx)
+
+	query := `SELECT v.id, v.url, COUNT(c.id) FROM link_variants v
+		INNER JOIN links l ON l.id = v.link_id
+		LEFT JOIN clicks c ON c.variant_id = v.id AND c.created_at >= $3 AND c.created_at <= $4
+		WHERE l.domain = $1 AND l.keyword = $2
+		GROUP BY v.id, v.url, v.position
+		ORDER BY v.position ASC`
+	log.Debug().Caller().Msg(query)
+
+	rows, err := s.db.QueryContext(ctx, query, domain, keyword, from, to)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return variants, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		v := model.LinkVariantClicks{}
+
+		if err = rows.Scan(&v.ID, &v.URL, &v.Total); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return variants, e.ErrInternalServerError
+		}
+
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
@@ -0,0 +1,115 @@
+package link
+
+import (
+	"testing"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+func TestChooseVariant(t *testing.T) {
+	a := model.LinkVariant{ID: "a", URL: "https://a.example.com", Weight: 1}
+	b := model.LinkVariant{ID: "b", URL: "https://b.example.com", Weight: 1}
+	never := model.LinkVariant{ID: "never", URL: "https://never.example.com", Weight: 0}
+	zero := model.LinkVariant{ID: "zero", URL: "https://zero.example.com", Weight: 0}
+
+	tests := []struct {
+		name      string
+		link      model.Link
+		variantID string
+		ok        bool
+		want      []string
+	}{
+		{"no variants", model.Link{}, "", false, nil},
+		{"one variant", model.Link{Variants: []model.LinkVariant{a}}, "", true, []string{"a"}},
+		{"by weight", model.Link{Variants: []model.LinkVariant{a, b}}, "", true, []string{"a", "b"}},
+		{"weight zero is never chosen", model.Link{Variants: []model.LinkVariant{never, a}}, "", true, []string{"a"}},
+		{"all weights zero is first", model.Link{Variants: []model.LinkVariant{zero, never}}, "", true, []string{"zero"}},
+		{"sticky keeps visitor variant", model.Link{StickyVariant: true, Variants: []model.LinkVariant{a, b}}, "b", true, []string{"b"}},
+		{"sticky with removed variant", model.Link{StickyVariant: true, Variants: []model.LinkVariant{a}}, "b", true, []string{"a"}},
+		{"not sticky ignores visitor variant", model.Link{Variants: []model.LinkVariant{a, never}}, "never", true, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Random choice, so try a few times.
+			for i := 0; i < 50; i++ {
+				v, ok := chooseVariant(tt.link, tt.variantID)
+				if ok != tt.ok {
+					t.Fatalf("chooseVariant() ok = %v; want %v", ok, tt.ok)
+				}
+
+				if ok && !contains(tt.want, v.ID) {
+					t.Fatalf("chooseVariant() = %q; want one of %v", v.ID, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeVariants(t *testing.T) {
+	old := []model.LinkVariant{{ID: "kept", URL: "https://old.example.com", Weight: 5}}
+
+	tests := []struct {
+		name     string
+		variants []model.LinkVariant
+		keepID   []bool
+		want     []model.LinkVariant
+	}{
+		{
+			name:     "empty",
+			variants: nil,
+			want:     []model.LinkVariant{},
+		},
+		{
+			name:     "trim URL and default weight",
+			variants: []model.LinkVariant{{URL: "  https://a.example.com "}},
+			keepID:   []bool{false},
+			want:     []model.LinkVariant{{URL: "https://a.example.com", Weight: 1}},
+		},
+		{
+			name:     "keep ID of old variant",
+			variants: []model.LinkVariant{{ID: "kept", URL: "https://new.example.com", Weight: 3}},
+			keepID:   []bool{true},
+			want:     []model.LinkVariant{{ID: "kept", URL: "https://new.example.com", Weight: 3}},
+		},
+		{
+			name:     "unknown ID is replaced",
+			variants: []model.LinkVariant{{ID: "unknown", URL: "https://a.example.com", Weight: 2}},
+			keepID:   []bool{false},
+			want:     []model.LinkVariant{{URL: "https://a.example.com", Weight: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeVariants(tt.variants, old)
+			if len(got) != len(tt.want) {
+				t.Fatalf("normalizeVariants() = %v; want %v", got, tt.want)
+			}
+
+			for i, v := range got {
+				want := tt.want[i]
+				if v.URL != want.URL || v.Weight != want.Weight {
+					t.Errorf("normalizeVariants()[%d] = %+v; want %+v", i, v, want)
+				}
+
+				if tt.keepID[i] && v.ID != want.ID {
+					t.Errorf("normalizeVariants()[%d].ID = %q; want %q", i, v.ID, want.ID)
+				}
+
+				if !tt.keepID[i] && (v.ID == "" || v.ID == tt.variants[i].ID) {
+					t.Errorf("normalizeVariants()[%d].ID = %q; want a new ID", i, v.ID)
+				}
+			}
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+)
+
+// defaultSyncInterval is used when CLICKS_SYNC_INTERVAL is zero or negative.
+const defaultSyncInterval = 60 * time.Second
+
+// NewWorker start a background process that drain click counters from cache to database.
+// So, if cache is flushed or some key is evicted, we don't lose click history.
+func (s service) NewWorker(ctx context.Context, interval time.Duration) {
+	log := logger.Logger(ctx)
+
+	// Ticker panics with non-positive interval.
+	if interval <= 0 {
+		log.Warn().Caller().Msg(fmt.Sprintf("invalid clicks sync interval %s, using %s", interval, defaultSyncInterval))
+		interval = defaultSyncInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info().Caller().Msg(fmt.Sprintf("clicks worker started with interval %s", interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Caller().Msg("clicks worker stopped")
+			return
+
+		case <-ticker.C:
+			if err := s.SyncClicks(ctx, interval); err != nil {
+				log.Error().Caller().Msg(err.Error())
+			}
+		}
+	}
+}
+
+// NewTrashWorker purge links in trash for more than "retention", checking every hour.
+func (s service) NewTrashWorker(ctx context.Context, retention time.Duration) {
+	log := logger.Logger(ctx)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	log.Info().Caller().Msg(fmt.Sprintf("trash worker started with retention %s", retention))
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Caller().Msg("trash worker stopped")
+			return
+
+		case <-ticker.C:
+			total, err := s.PurgeTrash(ctx, retention)
+			if err != nil {
+				log.Error().Caller().Msg(err.Error())
+				continue
+			}
+
+			if total > 0 {
+				log.Info().Caller().Msg(fmt.Sprintf("%d links purged from trash", total))
+			}
+		}
+	}
+}
+
+// SyncClicks persist hourly counters from every metric hash in cache.
+// Hashes checked by another worker in less than "interval" are skipped.
+func (s service) SyncClicks(ctx context.Context, interval time.Duration) (err error) {
+	log := logger.Logger(ctx)
+
+	pattern := fmt.Sprintf(keyMetricShortLink, "*", "*")
+	iter := s.cache.Scan(ctx, 0, pattern, 100).Iterator()
+
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		// Keep going with another keys, one broken link doesn't matter here.
+		if err := s.syncClicksKey(ctx, key, interval); err != nil {
+			log.Error().Caller().Msg(fmt.Sprintf("error to sync key '%s': %s", key, err.Error()))
+		}
+	}
+
+	return iter.Err()
+}
+
+// syncClicksKey upsert each hour bucket of a metric hash into links_clicks_hourly table.
+// The value saved is the absolute counter, not a delta, so run it twice is harmless.
+// Closed hours already persisted are removed from cache.
+func (s service) syncClicksKey(ctx context.Context, key string, interval time.Duration) (err error) {
+	log := logger.Logger(ctx)
+	now := time.Now()
+
+	values, err := s.cache.HGetAll(ctx, key).Result()
+	if err != nil {
+		return
+	}
+
+	if v, ok := values[keyLatestCheck]; ok {
+		latestCheck, _ := strconv.ParseInt(v, 10, 64)
+		if now.Sub(time.Unix(latestCheck, 0)) < interval/2 {
+			log.Debug().Caller().Msg(fmt.Sprintf("key '%s' was checked recently, skipping", key))
+			return
+		}
+	}
+
+	err = s.cache.HSet(ctx, key, keyLatestCheck, now.Unix()).Err()
+	if err != nil {
+		return
+	}
+
+	domain, keyword := splitMetricKey(key)
+
+	linkID := ""
+	query := "SELECT id FROM links WHERE domain = $1 AND keyword = $2"
+	log.Debug().Caller().Msg(query)
+
+	err = s.db.QueryRowContext(ctx, query, domain, keyword).Scan(&linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn().Caller().Msg(fmt.Sprintf("link with domain '%s' and keyword '%s' not found", domain, keyword))
+			return nil
+		}
+		return
+	}
+
+	synced := map[string]string{}
+	drained := []string{}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	query = `
+		INSERT INTO links_clicks_hourly(link_id, bucket, total, synced_at)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (link_id, bucket) DO UPDATE SET total = EXCLUDED.total, synced_at = EXCLUDED.synced_at
+	`
+
+	for field, value := range values {
+		if !strings.HasPrefix(field, fmt.Sprintf(keyMetricCounter, "")) {
+			continue
+		}
+
+		hour := strings.TrimPrefix(field, fmt.Sprintf(keyMetricCounter, ""))
+		bucket, err := time.Parse(layoutMetricCounter, hour)
+		if err != nil {
+			log.Warn().Caller().Msg(fmt.Sprintf("invalid counter field '%s' in key '%s'", field, key))
+			continue
+		}
+
+		// Give some time to late increments before remove a closed hour from cache.
+		closed := bucket.Add(2 * time.Hour).Before(now)
+		marker := fmt.Sprintf(keyLatestSync, hour)
+
+		if values[marker] == value {
+			if closed {
+				drained = append(drained, field, marker)
+			}
+			continue
+		}
+
+		total, _ := strconv.Atoi(value)
+
+		_, err = tx.ExecContext(ctx, query, linkID, bucket, total, now)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		synced[marker] = value
+	}
+
+	if len(synced) == 0 {
+		_ = tx.Rollback()
+		return s.drainClicksKey(ctx, key, drained)
+	}
+
+	query = `
+		INSERT INTO links_clicks(link_id, total)
+		SELECT link_id, SUM(total) FROM links_clicks_hourly WHERE link_id = $1 GROUP BY link_id
+		ON CONFLICT (link_id) DO UPDATE SET total = EXCLUDED.total
+	`
+
+	_, err = tx.ExecContext(ctx, query, linkID)
+	if err != nil {
+		_ = tx.Rollback()
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	err = s.cache.HSet(ctx, key, synced).Err()
+	if err != nil {
+		return
+	}
+
+	log.Debug().Caller().Msg(fmt.Sprintf("synced %d hours from key '%s'", len(synced), key))
+	return s.drainClicksKey(ctx, key, drained)
+}
+
+// drainClicksKey remove counters and sync markers already persisted in database.
+// A hash left with only the check time is removed too, so links without recent clicks don't stay in cache.
+func (s service) drainClicksKey(ctx context.Context, key string, fields []string) (err error) {
+	if len(fields) > 0 {
+		if err = s.cache.HDel(ctx, key, fields...).Err(); err != nil {
+			return
+		}
+	}
+	return dropChecked.Run(ctx, s.cache, []string{key}, keyLatestCheck).Err()
+}
+
+// dropChecked remove a metric hash with only the check time. It runs in Redis,
+// so a click counted between the check and the removal is not lost.
+var dropChecked = redis.NewScript(`
+if redis.call("HLEN", KEYS[1]) == 1 and redis.call("HEXISTS", KEYS[1], ARGV[1]) == 1 then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// splitMetricKey get domain and keyword from metric hash key.
+// Domain can have a port, like "localhost:8081", so keyword is after the last colon.
+func splitMetricKey(key string) (domain, keyword string) {
+	prefix := strings.TrimSuffix(fmt.Sprintf(keyMetricShortLink, "", ""), ":")
+	key = strings.TrimPrefix(key, prefix)
+
+	index := strings.LastIndex(key, ":")
+	if index < 0 {
+		return
+	}
+
+	return key[:index], key[index+1:]
+}
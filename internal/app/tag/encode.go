@@ -0,0 +1,7 @@
+package tag
+
+import "github.com/wvoliveira/corgi/internal/pkg/model"
+
+type listResponse struct {
+	Tags []model.Tag `json:"tags"`
+}
@@ -0,0 +1,200 @@
+package tag
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	maxNameLength        = 50
+	maxDescriptionLength = 300
+
+	// Postgres error code for unique violation.
+	uniqueViolation = "23505"
+)
+
+const tagColumns = `id, created_at, updated_at, name, description, user_id,
+	(SELECT COUNT(0) FROM link_tags WHERE tag_id = tags.id)`
+
+// Service encapsulates the tag logic, http handlers and another transport layer.
+type Service interface {
+	Add(*gin.Context, addRequest) (model.Tag, error)
+	List(*gin.Context, listRequest) ([]model.Tag, error)
+	FindByID(*gin.Context, findByIDRequest) (model.Tag, error)
+	Update(*gin.Context, updateRequest) (model.Tag, error)
+	Delete(*gin.Context, deleteRequest) error
+
+	NewHTTP(*gin.RouterGroup)
+	HTTPAdd(*gin.Context)
+	HTTPList(*gin.Context)
+	HTTPFindByID(*gin.Context)
+	HTTPUpdate(*gin.Context)
+	HTTPDelete(*gin.Context)
+}
+
+type service struct {
+	db    *sql.DB
+	cache *redis.Client
+}
+
+// NewService creates a new tag service.
+func NewService(db *sql.DB, cache *redis.Client) Service {
+	return service{db, cache}
+}
+
+// Add create a new tag to user.
+func (s service) Add(c *gin.Context, payload addRequest) (tag model.Tag, err error) {
+	if err = checkTag(payload.Name, payload.Description); err != nil {
+		return
+	}
+
+	tag.ID = ulid.Make().String()
+	tag.CreatedAt = time.Now()
+	tag.Name = payload.Name
+	tag.Description = payload.Description
+	tag.UserID = payload.WhoID
+
+	query := "INSERT INTO tags(id, created_at, name, description, user_id) VALUES($1, $2, $3, $4, $5)"
+
+	_, err = s.db.ExecContext(c, query, tag.ID, tag.CreatedAt, tag.Name, tag.Description, tag.UserID)
+	if err != nil {
+		return tag, encodeDatabaseError(c, err)
+	}
+	return
+}
+
+// List get all tags from user, by name.
+func (s service) List(c *gin.Context, payload listRequest) (tags []model.Tag, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + tagColumns + " FROM tags WHERE user_id = $1 ORDER BY name ASC"
+
+	rows, err := s.db.QueryContext(c, query, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tags, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	tags = []model.Tag{}
+
+	for rows.Next() {
+		tag := model.Tag{}
+
+		if err = scanTag(rows, &tag); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return tags, e.ErrInternalServerError
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// FindByID get a tag from user.
+func (s service) FindByID(c *gin.Context, payload findByIDRequest) (tag model.Tag, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + tagColumns + " FROM tags WHERE id = $1 AND user_id = $2"
+
+	err = scanTag(s.db.QueryRowContext(c, query, payload.TagID, payload.WhoID), &tag)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tag, e.ErrTagNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return tag, e.ErrInternalServerError
+	}
+	return
+}
+
+// Update change name or description from a tag.
+func (s service) Update(c *gin.Context, payload updateRequest) (tag model.Tag, err error) {
+	tag, err = s.FindByID(c, findByIDRequest{WhoID: payload.WhoID, TagID: payload.TagID})
+	if err != nil {
+		return
+	}
+
+	if payload.Name != nil {
+		tag.Name = *payload.Name
+	}
+
+	if payload.Description != nil {
+		tag.Description = *payload.Description
+	}
+
+	if err = checkTag(tag.Name, tag.Description); err != nil {
+		return
+	}
+
+	now := time.Now()
+	query := "UPDATE tags SET name = $1, description = $2, updated_at = $3 WHERE id = $4 AND user_id = $5"
+
+	_, err = s.db.ExecContext(c, query, tag.Name, tag.Description, now, tag.ID, payload.WhoID)
+	if err != nil {
+		return tag, encodeDatabaseError(c, err)
+	}
+
+	tag.UpdatedAt = &now
+	return
+}
+
+// Delete remove a tag. Links with this tag are kept.
+func (s service) Delete(c *gin.Context, payload deleteRequest) (err error) {
+	log := logger.Logger(c)
+
+	result, err := s.db.ExecContext(c, "DELETE FROM tags WHERE id = $1 AND user_id = $2", payload.TagID, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return e.ErrTagNotFound
+	}
+	return
+}
+
+// checkTag validate name and description length.
+func checkTag(name, description string) error {
+	if name == "" || len(name) > maxNameLength || len(description) > maxDescriptionLength {
+		return e.ErrTagInvalid
+	}
+	return nil
+}
+
+// encodeDatabaseError convert a duplicated name in e.ErrTagAlreadyExists.
+func encodeDatabaseError(c *gin.Context, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return e.ErrTagAlreadyExists
+	}
+
+	log := logger.Logger(c)
+	log.Error().Caller().Msg(err.Error())
+	return e.ErrInternalServerError
+}
+
+// scanTag read a row from tagColumns and fill nullable fields.
+func scanTag(row interface{ Scan(...any) error }, tag *model.Tag) (err error) {
+	err = row.Scan(&tag.ID, &tag.CreatedAt, &tag.UpdatedAtNull, &tag.Name, &tag.Description, &tag.UserID, &tag.Links)
+	if err != nil {
+		return
+	}
+
+	if tag.UpdatedAtNull.Valid {
+		tag.UpdatedAt = &tag.UpdatedAtNull.Time
+	}
+	return
+}
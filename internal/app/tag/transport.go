@@ -0,0 +1,99 @@
+package tag
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/response"
+)
+
+func (s service) NewHTTP(rg *gin.RouterGroup) {
+	r := rg.Group("/tags")
+
+	r.POST("", s.HTTPAdd)
+	r.GET("", s.HTTPList)
+	r.GET("/:id", s.HTTPFindByID)
+	r.PATCH("/:id", s.HTTPUpdate)
+	r.DELETE("/:id", s.HTTPDelete)
+}
+
+func (s service) HTTPAdd(c *gin.Context) {
+	payload, err := decodeAdd(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tag, err := s.Add(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, tag, "", http.StatusCreated)
+}
+
+func (s service) HTTPList(c *gin.Context) {
+	payload, err := decodeList(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tags, err := s.List(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, listResponse{Tags: tags}, "", http.StatusOK)
+}
+
+func (s service) HTTPFindByID(c *gin.Context) {
+	payload, err := decodeFindByID(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tag, err := s.FindByID(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, tag, "", http.StatusOK)
+}
+
+func (s service) HTTPUpdate(c *gin.Context) {
+	payload, err := decodeUpdate(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tag, err := s.Update(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, tag, "", http.StatusOK)
+}
+
+func (s service) HTTPDelete(c *gin.Context) {
+	payload, err := decodeDelete(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.Delete(c, payload)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/token"
 )
 
 type findMeRequest struct {
@@ -36,6 +38,28 @@ type findByUsernameRequest struct {
 	Username string `uri:"username" binding:"required"`
 }
 
+type tokensAddRequest struct {
+	whoID string
+	Name  string `json:"name" binding:"required"`
+	Scope string `json:"scope"`
+}
+
+type tokensListRequest struct {
+	whoID string
+}
+
+type tokensUpdateRequest struct {
+	whoID string
+	ID    string `uri:"id" binding:"required"`
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+type tokensDeleteRequest struct {
+	whoID string
+	ID    string `uri:"id" binding:"required"`
+}
+
 func decodeFindMe(c *gin.Context) (r findMeRequest, err error) {
 	v, ok := c.Get("user_id")
 	if !ok {
@@ -113,3 +137,74 @@ func decodeFindByUsername(c *gin.Context) (r findByUsernameRequest, err error) {
 	}
 	return
 }
+
+func decodeTokensAdd(c *gin.Context) (r tokensAddRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		return r, errors.New("impossible to know who you are")
+	}
+
+	r.whoID = v.(string)
+
+	if err = c.ShouldBindJSON(&r); err != nil {
+		return r, err
+	}
+
+	// Read only is the safer choice when scope was not set.
+	if r.Scope == "" {
+		r.Scope = token.ScopeRead
+	}
+
+	if r.Scope != token.ScopeRead && r.Scope != token.ScopeWrite {
+		return r, e.ErrTokenInvalidScope
+	}
+	return
+}
+
+func decodeTokensList(c *gin.Context) (r tokensListRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		return r, errors.New("impossible to know who you are")
+	}
+
+	r.whoID = v.(string)
+	return
+}
+
+func decodeTokensUpdate(c *gin.Context) (r tokensUpdateRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		return r, errors.New("impossible to know who you are")
+	}
+
+	r.whoID = v.(string)
+
+	err = c.ShouldBindUri(&r)
+	if err != nil {
+		return r, errors.New("impossible to get token ID from URI")
+	}
+
+	if err = c.ShouldBindJSON(&r); err != nil {
+		return r, err
+	}
+
+	if r.Scope != "" && r.Scope != token.ScopeRead && r.Scope != token.ScopeWrite {
+		return r, e.ErrTokenInvalidScope
+	}
+	return
+}
+
+func decodeTokensDelete(c *gin.Context) (r tokensDeleteRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		return r, errors.New("impossible to know who you are")
+	}
+
+	r.whoID = v.(string)
+
+	err = c.ShouldBindUri(&r)
+	if err != nil {
+		return r, errors.New("impossible to get token ID from URI")
+	}
+	return
+}
@@ -1,5 +1,11 @@
 package user
 
+import (
+	"time"
+
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
 type identity struct {
 	Provider string `json:"provider,omitempty"`
 	UID      string `json:"uid,omitempty"`
@@ -11,3 +17,32 @@ type userResponse struct {
 	Role       string     `json:"role,omitempty"`
 	Identities []identity `json:"identities,omitempty"`
 }
+
+// tokenResponse is a personal token. The plain token is only present at creation.
+type tokenResponse struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUse   *time.Time `json:"last_use"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	Token     string     `json:"token,omitempty"`
+}
+
+func encodeToken(t model.Token) tokenResponse {
+	return tokenResponse{
+		ID:        t.ID,
+		CreatedAt: t.CreatedAt,
+		LastUse:   t.LastUse,
+		Name:      t.Name,
+		Scope:     t.Scope,
+		Token:     t.Token,
+	}
+}
+
+func encodeTokens(tokens []model.Token) (r []tokenResponse) {
+	r = []tokenResponse{}
+	for _, t := range tokens {
+		r = append(r, encodeToken(t))
+	}
+	return
+}
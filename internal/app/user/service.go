@@ -3,12 +3,15 @@ package user
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
 	"github.com/wvoliveira/corgi/internal/pkg/model"
+	"github.com/wvoliveira/corgi/internal/pkg/token"
 )
 
 // Service encapsulates the link service logic, http handlers and another transport layer.
@@ -18,11 +21,19 @@ type Service interface {
 	FindByID(*gin.Context, string, string) (model.User, error)
 	UpdateByID(*gin.Context, string, string, string) error
 	FindByUsername(*gin.Context, string, string) (model.User, error)
+	TokensAdd(*gin.Context, tokensAddRequest) (model.Token, error)
+	TokensList(*gin.Context, string) ([]model.Token, error)
+	TokensUpdate(*gin.Context, tokensUpdateRequest) error
+	TokensDelete(*gin.Context, string, string) error
 
 	NewHTTP(*gin.RouterGroup)
 	HTTPFindByID(*gin.Context)
 	HTTPUpdateByID(*gin.Context)
 	HTTPFindByUsername(*gin.Context)
+	HTTPTokensAdd(*gin.Context)
+	HTTPTokensList(*gin.Context)
+	HTTPTokensUpdate(*gin.Context)
+	HTTPTokensDelete(*gin.Context)
 }
 
 type service struct {
@@ -69,6 +80,11 @@ func (s service) UpdateMe(c *gin.Context, whoID string, name string) (err error)
 		return
 	}
 
+	// Personal tokens keep user info in cache.
+	if err := token.DeleteUserPersonalTokens(c, s.db, s.cache, whoID); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
 	return
 }
 
@@ -109,6 +125,11 @@ func (s service) UpdateByID(c *gin.Context, whoID, id, name string) (err error)
 		return
 	}
 
+	// Personal tokens keep user info in cache.
+	if err := token.DeleteUserPersonalTokens(c, s.db, s.cache, id); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
 	return
 }
 
@@ -133,3 +154,146 @@ func (s service) FindByUsername(c *gin.Context, whoID string, username string) (
 
 	return
 }
+
+// TokensAdd create a personal token to use API without login, like in CI pipelines.
+// The plain token is returned only here, we save just its hash.
+func (s service) TokensAdd(c *gin.Context, payload tokensAddRequest) (t model.Token, err error) {
+	log := logger.Logger(c)
+
+	user, err := s.FindMe(c, payload.whoID)
+	if err != nil {
+		return
+	}
+
+	plain, hash, err := token.NewPersonalToken()
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return t, e.ErrInternalServerError
+	}
+
+	t.ID = ulid.Make().String()
+	t.CreatedAt = time.Now()
+	t.Name = payload.Name
+	t.Scope = payload.Scope
+	t.UserID = user.ID
+
+	query := "INSERT INTO tokens(id, created_at, name, scope, hash, user_id) VALUES($1, $2, $3, $4, $5, $6)"
+	_, err = s.db.ExecContext(c, query, t.ID, t.CreatedAt, t.Name, t.Scope, hash, t.UserID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return t, e.ErrInternalServerError
+	}
+
+	pt := token.PersonalToken{ID: t.ID, UserID: user.ID, Role: user.Role, Scope: t.Scope}
+
+	// Keep going on error from cache, token is loaded from database at first use.
+	if err := token.CachePersonalToken(c, s.cache, hash, pt); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	t.Token = plain
+	return t, nil
+}
+
+// TokensList get all personal tokens from user, without the plain tokens.
+func (s service) TokensList(c *gin.Context, whoID string) (tokens []model.Token, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT id, created_at, last_use, name, scope, user_id FROM tokens WHERE user_id = $1 ORDER BY id ASC"
+
+	rows, err := s.db.QueryContext(c, query, whoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return tokens, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	tokens = []model.Token{}
+
+	for rows.Next() {
+		t := model.Token{}
+
+		err = rows.Scan(&t.ID, &t.CreatedAt, &t.LastUseNull, &t.Name, &t.Scope, &t.UserID)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return tokens, e.ErrInternalServerError
+		}
+
+		if t.LastUseNull.Valid {
+			t.LastUse = &t.LastUseNull.Time
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return
+}
+
+// TokensUpdate change name or scope from a personal token.
+func (s service) TokensUpdate(c *gin.Context, payload tokensUpdateRequest) (err error) {
+	log := logger.Logger(c)
+
+	hash, err := s.tokenHash(c, payload.whoID, payload.ID)
+	if err != nil {
+		return
+	}
+
+	query := `UPDATE tokens SET name = COALESCE(NULLIF($1, ''), name), scope = COALESCE(NULLIF($2, ''), scope), updated_at = $3
+		WHERE id = $4 AND user_id = $5`
+
+	_, err = s.db.ExecContext(c, query, payload.Name, payload.Scope, time.Now(), payload.ID, payload.whoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	// Remove from cache, so the new scope is loaded from database at next use.
+	if err := token.DeletePersonalToken(c, s.cache, hash); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	return
+}
+
+// TokensDelete revoke a personal token. It stops working right away.
+func (s service) TokensDelete(c *gin.Context, whoID, id string) (err error) {
+	log := logger.Logger(c)
+
+	hash, err := s.tokenHash(c, whoID, id)
+	if err != nil {
+		return
+	}
+
+	err = token.DeletePersonalToken(c, s.cache, hash)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	_, err = s.db.ExecContext(c, "DELETE FROM tokens WHERE id = $1 AND user_id = $2", id, whoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	return
+}
+
+// tokenHash get hash from a personal token of user.
+func (s service) tokenHash(c *gin.Context, whoID, id string) (hash string, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT hash FROM tokens WHERE id = $1 AND user_id = $2"
+
+	err = s.db.QueryRowContext(c, query, id, whoID).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return hash, e.ErrTokenNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return hash, e.ErrInternalServerError
+	}
+
+	return
+}
@@ -13,6 +13,10 @@ func (s service) NewHTTP(rg *gin.RouterGroup) {
 
 	r.GET("/me", s.HTTPFindMe)
 	r.PATCH("/me", s.HTTPUpdateMe)
+	r.GET("/me/tokens", s.HTTPTokensList)
+	r.POST("/me/tokens", s.HTTPTokensAdd)
+	r.PATCH("/me/tokens/:id", s.HTTPTokensUpdate)
+	r.DELETE("/me/tokens/:id", s.HTTPTokensDelete)
 	r.GET("/:id", s.HTTPFindByID)
 	r.PATCH("/:id", s.HTTPUpdateByID)
 	r.GET("/username/:username", s.HTTPFindByUsername)
@@ -148,3 +152,68 @@ func (s service) HTTPFindByUsername(c *gin.Context) {
 
 	response.Default(c, resp, "", http.StatusOK)
 }
+
+func (s service) HTTPTokensAdd(c *gin.Context) {
+	d, err := decodeTokensAdd(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	t, err := s.TokensAdd(c, d)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	message := "copy this token now, it will not be shown again"
+	response.Default(c, encodeToken(t), message, http.StatusCreated)
+}
+
+func (s service) HTTPTokensList(c *gin.Context) {
+	d, err := decodeTokensList(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	tokens, err := s.TokensList(c, d.whoID)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, encodeTokens(tokens), "", http.StatusOK)
+}
+
+func (s service) HTTPTokensUpdate(c *gin.Context) {
+	d, err := decodeTokensUpdate(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.TokensUpdate(c, d)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
+
+func (s service) HTTPTokensDelete(c *gin.Context) {
+	d, err := decodeTokensDelete(c)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	err = s.TokensDelete(c, d.whoID, d.ID)
+	if err != nil {
+		e.EncodeError(c, err)
+		return
+	}
+
+	response.Default(c, nil, "", http.StatusOK)
+}
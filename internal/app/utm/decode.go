@@ -0,0 +1,128 @@
+package utm
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+type addRequest struct {
+	WhoID   string
+	Name    string `json:"name" binding:"required"`
+	GroupID string `json:"group_id"`
+	model.UTM
+}
+
+type listRequest struct {
+	WhoID string
+}
+
+type findByIDRequest struct {
+	WhoID    string
+	PresetID string `uri:"id" binding:"required"`
+}
+
+type updateRequest struct {
+	WhoID    string
+	PresetID string  `uri:"id" binding:"required"`
+	Name     *string `json:"name"`
+	Source   *string `json:"utm_source"`
+	Medium   *string `json:"utm_medium"`
+	Campaign *string `json:"utm_campaign"`
+	Term     *string `json:"utm_term"`
+	Content  *string `json:"utm_content"`
+}
+
+type deleteRequest struct {
+	WhoID    string
+	PresetID string `uri:"id" binding:"required"`
+}
+
+func decodeAdd(c *gin.Context) (req addRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	req.WhoID = v.(string)
+	req.Name = strings.TrimSpace(req.Name)
+	return req, nil
+}
+
+func decodeList(c *gin.Context) (req listRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeFindByID(c *gin.Context) (req findByIDRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get preset id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeUpdate(c *gin.Context) (req updateRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get preset id from path")
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		return req, err
+	}
+
+	if req.Name == nil && req.Source == nil && req.Medium == nil && req.Campaign == nil &&
+		req.Term == nil && req.Content == nil {
+		return req, e.ErrRequestNeedBody
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	req.WhoID = v.(string)
+	return
+}
+
+func decodeDelete(c *gin.Context) (req deleteRequest, err error) {
+	v, ok := c.Get("user_id")
+	if !ok {
+		err = errors.New("impossible to know who you are")
+		return
+	}
+
+	if err = c.ShouldBindUri(&req); err != nil {
+		return req, errors.New("impossible to get preset id from path")
+	}
+
+	req.WhoID = v.(string)
+	return
+}
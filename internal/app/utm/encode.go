@@ -0,0 +1,7 @@
+package utm
+
+import "github.com/wvoliveira/corgi/internal/pkg/model"
+
+type listResponse struct {
+	Presets []model.UTMPreset `json:"presets"`
+}
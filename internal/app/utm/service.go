@@ -0,0 +1,265 @@
+package utm
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
+)
+
+const (
+	maxNameLength = 50
+
+	// Postgres error code for unique violation.
+	uniqueViolation = "23505"
+)
+
+// Presets from user or from any group of user.
+const ownerFilter = "(user_id = $1 OR group_id IN (SELECT group_id FROM group_user WHERE user_id = $1))"
+
+const presetColumns = `id, created_at, updated_at, name, utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+	user_id, group_id, created_by`
+
+// Service encapsulates the UTM preset logic, http handlers and another transport layer.
+type Service interface {
+	Add(*gin.Context, addRequest) (model.UTMPreset, error)
+	List(*gin.Context, listRequest) ([]model.UTMPreset, error)
+	FindByID(*gin.Context, findByIDRequest) (model.UTMPreset, error)
+	Update(*gin.Context, updateRequest) (model.UTMPreset, error)
+	Delete(*gin.Context, deleteRequest) error
+
+	NewHTTP(*gin.RouterGroup)
+	HTTPAdd(*gin.Context)
+	HTTPList(*gin.Context)
+	HTTPFindByID(*gin.Context)
+	HTTPUpdate(*gin.Context)
+	HTTPDelete(*gin.Context)
+}
+
+type service struct {
+	db    *sql.DB
+	cache *redis.Client
+}
+
+// NewService creates a new UTM preset service.
+func NewService(db *sql.DB, cache *redis.Client) Service {
+	return service{db, cache}
+}
+
+// Add create a preset to user or to a group of user.
+func (s service) Add(c *gin.Context, payload addRequest) (preset model.UTMPreset, err error) {
+	log := logger.Logger(c)
+
+	if err = checkPreset(payload.Name, payload.UTM); err != nil {
+		return
+	}
+
+	if payload.GroupID != "" {
+		member := 0
+		query := "SELECT COUNT(0) FROM group_user WHERE group_id = $1 AND user_id = $2"
+
+		err = s.db.QueryRowContext(c, query, payload.GroupID, payload.WhoID).Scan(&member)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return preset, e.ErrInternalServerError
+		}
+
+		if member == 0 {
+			return preset, e.ErrGroupNotFound
+		}
+	}
+
+	preset.ID = ulid.Make().String()
+	preset.CreatedAt = time.Now()
+	preset.Name = payload.Name
+	preset.UTM = payload.UTM
+	preset.CreatedBy = payload.WhoID
+
+	if payload.GroupID != "" {
+		preset.GroupID = payload.GroupID
+		preset.GroupIDNull = sql.NullString{String: payload.GroupID, Valid: true}
+	} else {
+		preset.UserID = payload.WhoID
+		preset.UserIDNull = sql.NullString{String: payload.WhoID, Valid: true}
+	}
+
+	query := `INSERT INTO utm_presets(id, created_at, name, utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+			user_id, group_id, created_by)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err = s.db.ExecContext(c, query, preset.ID, preset.CreatedAt, preset.Name,
+		preset.Source, preset.Medium, preset.Campaign, preset.Term, preset.Content,
+		preset.UserIDNull, preset.GroupIDNull, preset.CreatedBy)
+
+	if err != nil {
+		return preset, encodeDatabaseError(c, err)
+	}
+	return
+}
+
+// List get presets from user and from groups of user, by name.
+func (s service) List(c *gin.Context, payload listRequest) (presets []model.UTMPreset, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + presetColumns + " FROM utm_presets WHERE " + ownerFilter + " ORDER BY name ASC, id ASC"
+
+	rows, err := s.db.QueryContext(c, query, payload.WhoID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return presets, e.ErrInternalServerError
+	}
+
+	defer rows.Close()
+	presets = []model.UTMPreset{}
+
+	for rows.Next() {
+		preset := model.UTMPreset{}
+
+		if err = scanPreset(rows, &preset); err != nil {
+			log.Error().Caller().Msg(err.Error())
+			return presets, e.ErrInternalServerError
+		}
+
+		presets = append(presets, preset)
+	}
+
+	return presets, rows.Err()
+}
+
+// FindByID get a preset from user or from groups of user.
+func (s service) FindByID(c *gin.Context, payload findByIDRequest) (preset model.UTMPreset, err error) {
+	log := logger.Logger(c)
+
+	query := "SELECT " + presetColumns + " FROM utm_presets WHERE id = $2 AND " + ownerFilter
+
+	err = scanPreset(s.db.QueryRowContext(c, query, payload.WhoID, payload.PresetID), &preset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return preset, e.ErrUTMPresetNotFound
+		}
+
+		log.Error().Caller().Msg(err.Error())
+		return preset, e.ErrInternalServerError
+	}
+	return
+}
+
+// Update change name or parameters of a preset.
+// Links created with it keep the parameters they had.
+func (s service) Update(c *gin.Context, payload updateRequest) (preset model.UTMPreset, err error) {
+	preset, err = s.FindByID(c, findByIDRequest{WhoID: payload.WhoID, PresetID: payload.PresetID})
+	if err != nil {
+		return
+	}
+
+	fields := []struct {
+		dst *string
+		src *string
+	}{
+		{&preset.Name, payload.Name},
+		{&preset.Source, payload.Source},
+		{&preset.Medium, payload.Medium},
+		{&preset.Campaign, payload.Campaign},
+		{&preset.Term, payload.Term},
+		{&preset.Content, payload.Content},
+	}
+
+	for _, f := range fields {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+
+	if err = checkPreset(preset.Name, preset.UTM); err != nil {
+		return
+	}
+
+	now := time.Now()
+	query := `UPDATE utm_presets SET name = $1, utm_source = $2, utm_medium = $3, utm_campaign = $4, utm_term = $5,
+		utm_content = $6, updated_at = $7
+		WHERE id = $8`
+
+	_, err = s.db.ExecContext(c, query, preset.Name, preset.Source, preset.Medium, preset.Campaign, preset.Term,
+		preset.Content, now, preset.ID)
+
+	if err != nil {
+		return preset, encodeDatabaseError(c, err)
+	}
+
+	preset.UpdatedAt = &now
+	return
+}
+
+// Delete remove a preset. Links created with it keep their parameters.
+func (s service) Delete(c *gin.Context, payload deleteRequest) (err error) {
+	log := logger.Logger(c)
+
+	query := "DELETE FROM utm_presets WHERE id = $2 AND " + ownerFilter
+
+	result, err := s.db.ExecContext(c, query, payload.WhoID, payload.PresetID)
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return e.ErrInternalServerError
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return e.ErrUTMPresetNotFound
+	}
+	return
+}
+
+// checkPreset validate name and parameters length.
+func checkPreset(name string, utm model.UTM) error {
+	if name == "" || len(name) > maxNameLength || !utm.Valid() {
+		return e.ErrUTMPresetInvalid
+	}
+	return nil
+}
+
+// encodeDatabaseError convert a duplicated name in e.ErrUTMPresetAlreadyExists.
+func encodeDatabaseError(c *gin.Context, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return e.ErrUTMPresetAlreadyExists
+	}
+
+	log := logger.Logger(c)
+	log.Error().Caller().Msg(err.Error())
+	return e.ErrInternalServerError
+}
+
+// scanPreset read a row from presetColumns and fill nullable fields.
+func scanPreset(row interface{ Scan(...any) error }, preset *model.UTMPreset) (err error) {
+	err = row.Scan(
+		&preset.ID,
+		&preset.CreatedAt,
+		&preset.UpdatedAtNull,
+		&preset.Name,
+		&preset.Source,
+		&preset.Medium,
+		&preset.Campaign,
+		&preset.Term,
+		&preset.Content,
+		&preset.UserIDNull,
+		&preset.GroupIDNull,
+		&preset.CreatedBy,
+	)
+	if err != nil {
+		return
+	}
+
+	if preset.UpdatedAtNull.Valid {
+		preset.UpdatedAt = &preset.UpdatedAtNull.Time
+	}
+
+	preset.UserID = preset.UserIDNull.String
+	preset.GroupID = preset.GroupIDNull.String
+	return
+}
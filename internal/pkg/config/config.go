@@ -20,6 +20,38 @@ func New() {
 	viper.SetDefault("DOMAIN_DEFAULT", "localhost:8081")
 	viper.SetDefault("DOMAIN_ALTERNATIVES", []string{})
 
+	// DNS server to verify custom domains, like "127.0.0.1:53". Empty is the system resolver.
+	viper.SetDefault("DOMAIN_VERIFY_RESOLVER", "")
+
+	// Internal networks that HTTP verification of custom domains can request, like "127.0.0.1/32".
+	// Empty allow only public addresses, keep it that way in production.
+	viper.SetDefault("DOMAIN_VERIFY_HTTP_ALLOW", []string{})
+
+	// Maximum of items in each batch request to create, update or delete links.
+	viper.SetDefault("LINKS_BATCH_MAX", 1000)
+
+	// Maximum of rows in each file to import links.
+	viper.SetDefault("LINKS_IMPORT_MAX", 10000)
+
+	// Maximum size in megabytes of each file to import links.
+	viper.SetDefault("LINKS_IMPORT_MAX_SIZE", 10)
+
+	// Days that deleted links stay in trash before purged forever. Zero keep them forever.
+	viper.SetDefault("LINKS_TRASH_RETENTION_DAYS", 30)
+
+	// Redirect type of links without one: 301, 302, 307, 308 or "meta".
+	// 301 is how links always redirected. Browsers cache 301 and 308 forever,
+	// so changes in destination never reach returning visitors, use 302 if that matters.
+	// Links that expire or change by time or visitor use 302 and 307 instead of 301 and 308.
+	viper.SetDefault("LINKS_REDIRECT_TYPE", "301")
+
+	// HTML template shown by links outside of their window, without an unavailable URL.
+	// Empty is the built-in page.
+	viper.SetDefault("LINKS_UNAVAILABLE_PAGE", "")
+
+	// Interval in seconds to persist click counters from cache to database.
+	viper.SetDefault("CLICKS_SYNC_INTERVAL", 60)
+
 	// We can define config variables with prefix CORGI
 	// Ex.:
 	// 	- CORGI_LOG_LEVEL=debug
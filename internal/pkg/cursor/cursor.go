@@ -0,0 +1,50 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/oklog/ulid/v2"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+)
+
+// Cursor is the position of the last item of a page. Next page starts after it.
+// ID is the ULID of the item, used alone when list is sorted by ID or to break
+// ties when list is sorted by another column, with its value in Value.
+type Cursor struct {
+	ID    string `json:"id"`
+	Sort  string `json:"s,omitempty"`
+	Value string `json:"v,omitempty"`
+}
+
+// String encode cursor in an opaque token, safe for query strings.
+func (c Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Parse decode a token created by String. Empty token is an empty cursor.
+func Parse(token string) (c Cursor, err error) {
+	if token == "" {
+		return
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, e.ErrInvalidCursor
+	}
+
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, e.ErrInvalidCursor
+	}
+
+	if _, err = ulid.ParseStrict(c.ID); err != nil {
+		return c, e.ErrInvalidCursor
+	}
+	return
+}
+
+// IsZero is true when there is no cursor, so list starts from beginning.
+func (c Cursor) IsZero() bool {
+	return c.ID == ""
+}
@@ -0,0 +1,48 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+)
+
+func TestParse(t *testing.T) {
+	id := "01HKQ1Z9W5X6M2V3B4N5P6Q7R8"
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    Cursor
+		wantErr bool
+	}{
+		{"empty", "", Cursor{}, false},
+		{"id", Cursor{ID: id}.String(), Cursor{ID: id}, false},
+		{"sort and value", Cursor{ID: id, Sort: "title", Value: "Go blog"}.String(), Cursor{ID: id, Sort: "title", Value: "Go blog"}, false},
+		{"not base64", "not base64!", Cursor{}, true},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"id":"` + id + `"}`)), Cursor{}, true},
+		{"not json", encode("id=" + id), Cursor{}, true},
+		{"without id", encode(`{"s":"title","v":"a"}`), Cursor{}, true},
+		{"invalid id", encode(`{"id":"42"}`), Cursor{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, e.ErrInvalidCursor) {
+					t.Errorf("Parse() error = %v; want %v", err, e.ErrInvalidCursor)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("Parse() = %+v, %v; want %+v, nil", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -25,8 +25,12 @@ var (
 		Token errors.
 	 **/
 
-	ErrTokenInvalid = errors.New("invalid token")
-	ErrTokenType    = errors.New("type token must be 'auth' or 'personal'")
+	ErrTokenInvalid       = errors.New("invalid token")
+	ErrTokenType          = errors.New("type token must be 'auth' or 'personal'")
+	ErrTokenRefreshReused = errors.New("refresh token was already used, login again")
+	ErrTokenRevoked       = errors.New("token was revoked, login again")
+	ErrTokenNotFound      = errors.New("personal token not found")
+	ErrTokenInvalidScope  = errors.New("scope of personal token must be 'read' or 'write'")
 
 	// ErrUnauthorized default authentication error.
 	ErrUnauthorized     = errors.New("sorry, you are not unauthorized")
@@ -72,6 +76,32 @@ var (
 	ErrLinkInvalidKeyword      = errors.New("try to input a valid keyword between 6 and 15 chars")
 	ErrLinkKeywordNotPermitted = errors.New("this keyword is not permitted")
 	ErrLinkInvalidURL          = errors.New("try to input a valid destination (URL)")
+	ErrLinkExpired             = errors.New("this link has expired")
+	ErrLinkUnavailable         = errors.New("this link is not available now")
+	ErrLinkInvalidExpiration   = errors.New("expiration date must be in the future")
+	ErrLinkInvalidMaxClicks    = errors.New("maximum of clicks must be zero (unlimited) or more")
+	ErrLinkInvalidFallbackURL  = errors.New("try to input a valid fallback URL")
+	ErrLinkInvalidPassword     = errors.New("password must have between 4 and 72 chars")
+	ErrLinkPasswordRequired    = errors.New("this link is protected, send its password to continue")
+	ErrLinkPasswordWrong       = errors.New("wrong password for this link")
+	ErrLinkPasswordAttempts    = errors.New("too many wrong passwords, try again later")
+	ErrLinkBatchSize           = errors.New("batch must have at least one item and no more than the maximum permitted")
+	ErrLinkInvalidFormat       = errors.New("format must be 'csv' or 'ndjson', or 'bitly' and 'yourls' to import")
+	ErrLinkImportInvalidFile   = errors.New("impossible to read links from this file")
+	ErrLinkImportProtected     = errors.New("protected links can't be imported, create them again with a password")
+	ErrLinkImportTooLarge      = errors.New("file is larger than the maximum permitted to import")
+
+	ErrLinkImportInvalidConflict = errors.New("conflict must be 'skip', 'overwrite' or 'rename'")
+	ErrLinkInvalidSort           = errors.New("sort must be 'created_at', 'title', 'keyword' or 'clicks', and order 'asc' or 'desc'")
+	ErrLinkInvalidRedirectType   = errors.New("redirect type must be '301', '302', '307', '308' or 'meta'")
+	ErrLinkInvalidFilter         = errors.New("filter 'active' must be true or false, and 'created_from' and 'created_to' in RFC3339 format")
+	ErrLinkInvalidUTM            = errors.New("each UTM parameter must have up to 100 chars")
+	ErrLinkInvalidSchedule       = errors.New("window must end after start, schedule needs a valid timezone, days from 'mon' to 'sun' and hours like '09:00', and unavailable URL must be valid")
+	ErrLinkInvalidVariant        = errors.New("variants must have up to 10 items, each one with a valid URL and a weight between 1 and 1000")
+	ErrLinkInvalidTarget         = errors.New("targets must have up to 20 rules, each one with a valid URL and a known device, os, browser or country")
+
+	ErrLinkClicksInvalidGranularity = errors.New("granularity must be 'hour', 'day', 'week' or 'month'")
+	ErrLinkClicksTooManyPoints      = errors.New("too many points in series, try a shorter period or a bigger granularity")
 
 	// With anonymous access, we can not create a shortener link with same URL.
 	ErrAnonymousURLAlreadyExists = errors.New("with anonymous access, we can not create a shortener link with same URL")
@@ -91,6 +121,63 @@ var (
 	ErrGroupAlreadyExists       = errors.New("group with this name already exists. Choose another one")
 	ErrGroupNotFound            = errors.New("group with this ID was not found")
 	ErrGroupInviteAlreadyExists = errors.New("this invite already exists. You need wait for response user")
+
+	/**
+		Domain errors.
+	**/
+
+	ErrDomainNotFound           = errors.New("domain with this ID was not found")
+	ErrDomainInvalid            = errors.New("try to input a valid domain, like go.example.com")
+	ErrDomainAlreadyExists      = errors.New("this domain is already registered")
+	ErrDomainInvalidMethod      = errors.New("verification method must be 'dns' or 'http'")
+	ErrDomainVerificationFailed = errors.New("verification token was not found in domain, check the instructions and try again")
+	ErrDomainInUse              = errors.New("this domain has links, delete them first")
+
+	/**
+		Tag errors.
+	**/
+
+	ErrTagNotFound      = errors.New("tag with this ID was not found")
+	ErrTagInvalid       = errors.New("tag name must have between 1 and 50 chars and description up to 300 chars")
+	ErrTagAlreadyExists = errors.New("you already have a tag with this name")
+
+	/**
+		Folder errors.
+	**/
+
+	ErrFolderNotFound      = errors.New("folder with this ID was not found")
+	ErrFolderInvalid       = errors.New("folder name must have between 1 and 50 chars")
+	ErrFolderAlreadyExists = errors.New("you already have a folder with this name")
+
+	/**
+		UTM preset errors.
+	**/
+
+	ErrUTMPresetNotFound      = errors.New("UTM preset with this ID was not found")
+	ErrUTMPresetInvalid       = errors.New("preset name must have between 1 and 50 chars and each UTM parameter up to 100 chars")
+	ErrUTMPresetAlreadyExists = errors.New("a UTM preset with this name already exists")
+
+	/**
+		QR code errors.
+	**/
+
+	ErrQRInvalidFormat = errors.New("QR code format must be 'png' or 'svg'")
+	ErrQRInvalidSize   = errors.New("QR code size must be between 64 and 2048 pixels")
+	ErrQRInvalidLevel  = errors.New("QR code error correction level must be 'L', 'M', 'Q' or 'H'")
+	ErrQRInvalidMargin = errors.New("QR code margin must be between 0 and 16 modules")
+	ErrQRInvalidColor  = errors.New("QR code colors must be in hex format, like 'ff8800'")
+
+	/**
+		Click errors.
+	**/
+
+	ErrClickInvalidTimestamp = errors.New("timestamps 'tsf' and 'tst' must be in RFC3339 format")
+
+	/**
+		Pagination errors.
+	**/
+
+	ErrInvalidCursor = errors.New("cursor is invalid, use 'next_cursor' from a previous response with same sort")
 )
 
 type response struct {
@@ -127,19 +214,43 @@ func EncodeError(c *gin.Context, err error) {
 
 func codeFrom(err error) int {
 	switch err {
-	case ErrNotFound, ErrLinkNotFound, ErrGroupNotFound:
+	case ErrNotFound, ErrLinkNotFound, ErrGroupNotFound, ErrTokenNotFound, ErrDomainNotFound,
+		ErrTagNotFound, ErrUTMPresetNotFound, ErrFolderNotFound:
 		return http.StatusNotFound
 
 	case ErrRequestNeedBody, ErrInconsistentIDs,
-		ErrLinkInvalidDomain, ErrLinkInvalidKeyword, ErrLinkKeywordNotPermitted, ErrLinkInvalidURL:
+		ErrLinkInvalidDomain, ErrLinkInvalidKeyword, ErrLinkKeywordNotPermitted, ErrLinkInvalidURL,
+		ErrLinkInvalidExpiration, ErrLinkInvalidMaxClicks, ErrLinkInvalidFallbackURL, ErrLinkInvalidPassword,
+		ErrTokenInvalidScope, ErrLinkClicksInvalidGranularity, ErrLinkClicksTooManyPoints, ErrClickInvalidTimestamp,
+		ErrDomainInvalid, ErrDomainInvalidMethod, ErrDomainVerificationFailed, ErrLinkBatchSize,
+		ErrLinkInvalidFormat, ErrLinkImportInvalidFile, ErrLinkImportProtected, ErrLinkImportInvalidConflict,
+		ErrQRInvalidFormat, ErrQRInvalidSize, ErrQRInvalidLevel, ErrQRInvalidMargin, ErrQRInvalidColor,
+		ErrTagInvalid, ErrFolderInvalid, ErrLinkInvalidSort, ErrLinkInvalidFilter, ErrInvalidCursor,
+		ErrLinkInvalidRedirectType, ErrLinkInvalidUTM, ErrUTMPresetInvalid,
+		ErrLinkInvalidTarget, ErrLinkInvalidVariant, ErrLinkInvalidSchedule:
 		return http.StatusBadRequest
 
-	case ErrAlreadyExists, ErrLinkAlreadyExists, ErrAnonymousURLAlreadyExists, ErrAuthPasswordUserAlreadyExists:
+	case ErrAlreadyExists, ErrLinkAlreadyExists, ErrAnonymousURLAlreadyExists, ErrAuthPasswordUserAlreadyExists,
+		ErrDomainAlreadyExists, ErrDomainInUse, ErrTagAlreadyExists, ErrUTMPresetAlreadyExists,
+		ErrFolderAlreadyExists:
 		return http.StatusConflict
 
-	case ErrUnauthorized, ErrNoTokenFound, ErrParseToken, ErrTokenExpired:
+	case ErrLinkExpired:
+		return http.StatusGone
+
+	case ErrLinkUnavailable:
+		return http.StatusServiceUnavailable
+
+	case ErrUnauthorized, ErrNoTokenFound, ErrParseToken, ErrTokenExpired, ErrAuthHeaderFormat,
+		ErrTokenInvalid, ErrTokenRefreshReused, ErrTokenRevoked, ErrLinkPasswordRequired, ErrLinkPasswordWrong:
 		return http.StatusUnauthorized
 
+	case ErrLinkPasswordAttempts:
+		return http.StatusTooManyRequests
+
+	case ErrLinkImportTooLarge:
+		return http.StatusRequestEntityTooLarge
+
 	default:
 		return http.StatusInternalServerError
 	}
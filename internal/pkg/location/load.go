@@ -0,0 +1,266 @@
+package location
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+)
+
+const (
+	// FormatDBIP is the layout from DB-IP lite databases.
+	// Ex.: ip_start,ip_end,continent,country,stateprov,city,latitude,longitude
+	// Country lite database has only: ip_start,ip_end,country
+	FormatDBIP = "dbip"
+
+	// FormatIP2Location is the layout from IP2Location LITE databases, with IP as integer.
+	// Ex.: ip_from,ip_to,country_code,country_name,region_name,city_name,latitude,longitude
+	FormatIP2Location = "ip2location"
+
+	// Rows per INSERT statement. Each row has 8 params and Postgres accept 65535 at most.
+	loadBatchSize = 1000
+)
+
+var (
+	maxIPv4 = big.NewInt(0xffffffff)
+
+	// IPv4 mapped inside IPv6, like ::ffff:1.2.3.4. IP2Location IPv6 databases have these ranges.
+	mappedIPv4Start = new(big.Int).SetUint64(0xffff00000000)
+	mappedIPv4End   = new(big.Int).SetUint64(0xffffffffffff)
+)
+
+type locationRange struct {
+	start     *big.Int
+	end       *big.Int
+	country   string
+	state     string
+	city      string
+	latitude  float64
+	longitude float64
+}
+
+// Load import a CSV with IP ranges into locations_ipv4 and locations_ipv6 tables.
+// All previous ranges are replaced in one transaction, so lookups never see a half database.
+func Load(ctx context.Context, db *sql.DB, r io.Reader, format string) (total int, err error) {
+	log := logger.Logger(ctx)
+
+	parse, err := parser(format)
+	if err != nil {
+		return
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, table := range []string{"locations_ipv4", "locations_ipv6"} {
+		if _, err = tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return
+		}
+	}
+
+	ipv4 := []locationRange{}
+	ipv6 := []locationRange{}
+	line := 0
+
+	for {
+		record, errRead := reader.Read()
+		if errors.Is(errRead, io.EOF) {
+			break
+		}
+
+		line++
+		if errRead != nil {
+			return total, fmt.Errorf("line %d: %w", line, errRead)
+		}
+
+		loc, ok, errParse := parse(record)
+		if errParse != nil {
+			// Header or comment lines are not ranges, skip them.
+			log.Debug().Caller().Msg(fmt.Sprintf("line %d skipped: %s", line, errParse.Error()))
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if loc.end.Cmp(maxIPv4) <= 0 {
+			ipv4 = append(ipv4, loc)
+		} else {
+			ipv6 = append(ipv6, loc)
+		}
+
+		if len(ipv4) == loadBatchSize {
+			if err = insertRanges(ctx, tx, "locations_ipv4", ipv4); err != nil {
+				return
+			}
+			total += len(ipv4)
+			ipv4 = ipv4[:0]
+		}
+
+		if len(ipv6) == loadBatchSize {
+			if err = insertRanges(ctx, tx, "locations_ipv6", ipv6); err != nil {
+				return
+			}
+			total += len(ipv6)
+			ipv6 = ipv6[:0]
+		}
+	}
+
+	if err = insertRanges(ctx, tx, "locations_ipv4", ipv4); err != nil {
+		return
+	}
+
+	if err = insertRanges(ctx, tx, "locations_ipv6", ipv6); err != nil {
+		return
+	}
+
+	total += len(ipv4) + len(ipv6)
+	err = tx.Commit()
+	return
+}
+
+// parser return a function to convert a CSV record in a location range by format.
+// The bool is false when record is valid but is not useful, like reserved ranges.
+func parser(format string) (func([]string) (locationRange, bool, error), error) {
+	switch format {
+	case FormatDBIP:
+		return parseDBIP, nil
+	case FormatIP2Location:
+		return parseIP2Location, nil
+	}
+	return nil, fmt.Errorf("unknown format '%s', use '%s' or '%s'", format, FormatDBIP, FormatIP2Location)
+}
+
+func parseDBIP(record []string) (loc locationRange, ok bool, err error) {
+	if len(record) < 3 {
+		return loc, false, errors.New("expected at least 3 columns")
+	}
+
+	start := net.ParseIP(record[0])
+	end := net.ParseIP(record[1])
+	if start == nil || end == nil {
+		return loc, false, fmt.Errorf("invalid IP range '%s-%s'", record[0], record[1])
+	}
+
+	loc.start = ipToInt(start)
+	loc.end = ipToInt(end)
+
+	// Country lite database.
+	if len(record) < 8 {
+		loc.country = record[2]
+		return loc, loc.country != "ZZ", nil
+	}
+
+	loc.country = record[3]
+	loc.state = record[4]
+	loc.city = record[5]
+	loc.latitude, _ = strconv.ParseFloat(record[6], 64)
+	loc.longitude, _ = strconv.ParseFloat(record[7], 64)
+	return loc, loc.country != "ZZ", nil
+}
+
+func parseIP2Location(record []string) (loc locationRange, ok bool, err error) {
+	if len(record) < 3 {
+		return loc, false, errors.New("expected at least 3 columns")
+	}
+
+	start, okStart := new(big.Int).SetString(record[0], 10)
+	end, okEnd := new(big.Int).SetString(record[1], 10)
+	if !okStart || !okEnd {
+		return loc, false, fmt.Errorf("invalid IP range '%s-%s'", record[0], record[1])
+	}
+
+	// Move IPv4 mapped ranges to IPv4 table, because lookups use the IPv4 form.
+	if start.Cmp(mappedIPv4Start) >= 0 && end.Cmp(mappedIPv4End) <= 0 {
+		start.Sub(start, mappedIPv4Start)
+		end.Sub(end, mappedIPv4Start)
+	}
+
+	loc.start = start
+	loc.end = end
+	loc.country = record[2]
+
+	if len(record) >= 6 {
+		loc.state = record[4]
+		loc.city = record[5]
+	}
+
+	if len(record) >= 8 {
+		loc.latitude, _ = strconv.ParseFloat(record[6], 64)
+		loc.longitude, _ = strconv.ParseFloat(record[7], 64)
+	}
+
+	// Reserved and unknown ranges are "-".
+	if loc.state == "-" {
+		loc.state = ""
+	}
+
+	if loc.city == "-" {
+		loc.city = ""
+	}
+
+	return loc, loc.country != "-", nil
+}
+
+// insertRanges send a batch of ranges with only one INSERT statement.
+func insertRanges(ctx context.Context, tx *sql.Tx, table string, ranges []locationRange) (err error) {
+	if len(ranges) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(ranges))
+	args := make([]any, 0, len(ranges)*8)
+
+	for i, loc := range ranges {
+		n := i * 8
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+
+		args = append(args,
+			ulid.Make().String(),
+			loc.start.String(),
+			loc.end.String(),
+			loc.country,
+			loc.state,
+			loc.city,
+			loc.latitude,
+			loc.longitude,
+		)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s(id, range_start, range_end, country, state, city, latitude, longitude)
+		VALUES %s ON CONFLICT DO NOTHING`, table, strings.Join(values, ", "))
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	return
+}
+
+// ipToInt convert IPv4 to its uint32 value and IPv6 to 128 bits value.
+func ipToInt(ip net.IP) *big.Int {
+	if v4 := ip.To4(); v4 != nil {
+		return new(big.Int).SetBytes(v4)
+	}
+	return new(big.Int).SetBytes(ip.To16())
+}
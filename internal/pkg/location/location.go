@@ -2,11 +2,23 @@ package location
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
 	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+	"github.com/wvoliveira/corgi/internal/pkg/model"
 )
 
+const keyCacheLocation = "cache:location:%s" // Ex.: cache:location:<ip>
+
 // IPv4ToLong convert ipv4 to uint32.
 func IPv4ToLong(ip string) (i uint32) {
 	_ = binary.Read(bytes.NewBuffer(net.ParseIP(ip).To4()), binary.BigEndian, &i)
@@ -18,3 +30,74 @@ func IPv6ToLong(ip string) (b *big.Int) {
 	IPv6Int := big.NewInt(0)
 	return IPv6Int.SetBytes(net.ParseIP(ip).To16())
 }
+
+// Find get country, state and city from an IP address.
+// Return a blank location if IP is not found in any range, like private addresses.
+// Results are cached for one day, because IP ranges don't change often.
+func Find(ctx context.Context, db *sql.DB, cache *redis.Client, ip string) (loc model.Location, err error) {
+	log := logger.Logger(ctx)
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return loc, fmt.Errorf("invalid IP address '%s'", ip)
+	}
+
+	key := fmt.Sprintf(keyCacheLocation, ip)
+
+	val, err := cache.Get(ctx, key).Result()
+	if err == nil {
+		err = json.Unmarshal([]byte(val), &loc)
+		return
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		// Keep going on error from cache.
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	var query string
+	var value any
+	var rangeEnd string
+
+	if parsed.To4() != nil {
+		query = `SELECT range_end, country, state, city, latitude, longitude FROM locations_ipv4
+			WHERE range_start <= $1 ORDER BY range_start DESC LIMIT 1`
+		value = int64(IPv4ToLong(ip))
+	} else {
+		query = `SELECT range_end, country, state, city, latitude, longitude FROM locations_ipv6
+			WHERE range_start <= $1::numeric ORDER BY range_start DESC LIMIT 1`
+		value = IPv6ToLong(ip).String()
+	}
+
+	log.Debug().Caller().Msg(query)
+
+	var country, state, city sql.NullString
+	var latitude, longitude sql.NullFloat64
+
+	err = db.QueryRowContext(ctx, query, value).Scan(&rangeEnd, &country, &state, &city, &latitude, &longitude)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Caller().Msg(err.Error())
+		return
+	}
+
+	end, _ := new(big.Int).SetString(rangeEnd, 10)
+	current, _ := new(big.Int).SetString(fmt.Sprint(value), 10)
+
+	// The nearest range start can be from a range that already finished before this IP.
+	if err == nil && end != nil && current.Cmp(end) <= 0 {
+		loc = model.Location{
+			Country:   country.String,
+			State:     state.String,
+			City:      city.String,
+			Latitude:  latitude.Float64,
+			Longitude: longitude.Float64,
+		}
+	}
+
+	b, _ := json.Marshal(loc)
+	if err := cache.Set(ctx, key, b, 24*time.Hour).Err(); err != nil {
+		log.Error().Caller().Msg(err.Error())
+	}
+
+	return loc, nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/casbin/casbin/v2"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	e "github.com/wvoliveira/corgi/internal/pkg/errors"
 	"github.com/wvoliveira/corgi/internal/pkg/logger"
 	"github.com/wvoliveira/corgi/internal/pkg/model"
@@ -15,7 +17,9 @@ import (
 )
 
 // Authentication check if auth ok and set claims in request header.
-func Authentication() gin.HandlerFunc {
+// Revoked tokens are checked in cache, so logout works before token expiration.
+// Personal tokens are accepted with header "Authorization: Token <token>".
+func Authentication(db *sql.DB, cache *redis.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.Logger(c)
 
@@ -29,11 +33,30 @@ func Authentication() gin.HandlerFunc {
 			user.Role = "anon"
 		}
 
+		if strings.HasPrefix(headerAuth, "Token ") {
+			pt, err := token.ValidatePersonalToken(c, db, cache, strings.TrimPrefix(headerAuth, "Token "))
+
+			if err != nil {
+				log.Error().Caller().Msg(err.Error())
+				user.ID = "0"
+				user.Role = "anon"
+			} else {
+				user.ID = pt.UserID
+				user.Role = pt.Role
+				c.Set("token_scope", pt.Scope)
+			}
+
+			c.Set("user_id", user.ID)
+			c.Set("user_role", user.Role)
+			c.Next()
+			return
+		}
+
 		headerToken := strings.Split(headerAuth, "Bearer ")
 		accessToken := headerToken[len(headerToken)-1]
 
 		if headerAuth != "" && accessToken != "" {
-			user, err = token.ValidateToken(accessToken)
+			user, err = token.ValidateToken(c, cache, accessToken)
 
 			if err != nil {
 				log.Error().Caller().Msg(err.Error())
@@ -86,6 +109,16 @@ func Authorization(en *casbin.Enforcer) gin.HandlerFunc {
 			return
 		}
 
+		// Personal tokens have a scope narrower than user role.
+		if v, ok := c.Get("token_scope"); ok {
+			if !token.ScopeAllows(v.(string), act, c.Request.URL.Path) {
+				log.Debug().Caller().Msg(fmt.Sprintf("Deny by personal token scope '%s'", v.(string)))
+				e.EncodeError(c, e.ErrUnauthorized)
+				c.Abort()
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
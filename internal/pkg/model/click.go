@@ -1,5 +1,21 @@
 package model
 
+import "time"
+
+// Click represents a redirect served by a short link.
 type Click struct {
-	Timestamp string `json:"timestamp"`
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+
+	Referer   string `json:"referer"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	Language  string `json:"language"`
+
+	Country string `json:"country"`
+	State   string `json:"state"`
+	City    string `json:"city"`
+
+	LinkID    string `json:"link_id"`
+	VariantID string `json:"variant_id,omitempty"`
 }
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Domain represents a custom domain from a user or a group.
+// Links can use it only after ownership verification.
+type Domain struct {
+	ID             string       `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      *time.Time   `json:"updated_at"`
+	UpdatedAtNull  sql.NullTime `json:"-"`
+	VerifiedAt     *time.Time   `json:"verified_at"`
+	VerifiedAtNull sql.NullTime `json:"-"`
+
+	Name  string `json:"name"`
+	Token string `json:"token"`
+
+	UserID      string         `json:"user_id,omitempty"`
+	UserIDNull  sql.NullString `json:"-"`
+	GroupID     string         `json:"group_id,omitempty"`
+	GroupIDNull sql.NullString `json:"-"`
+	CreatedBy   string         `json:"created_by"`
+}
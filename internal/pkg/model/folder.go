@@ -0,0 +1,21 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Folder represents a place from user to keep links, like a project. A link is in one folder at most.
+type Folder struct {
+	ID            string       `json:"id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+	UpdatedAtNull sql.NullTime `json:"-"`
+
+	Name string `json:"name"`
+
+	// Total of links in this folder.
+	Links int `json:"links"`
+
+	UserID string `json:"-"`
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"database/sql"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Link represents a link record.
@@ -18,11 +20,134 @@ type Link struct {
 	Title   string `json:"title"`
 	Active  string `json:"active"`
 
+	// Deleted links stay in trash until restored or purged.
+	DeletedAt     *time.Time   `json:"deleted_at,omitempty"`
+	DeletedAtNull sql.NullTime `json:"-"`
+
+	// Optional expiration by date or by clicks (0 is unlimited).
+	// After that, redirect goes to FallbackURL, if any.
+	ExpiresAt     *time.Time   `json:"expires_at"`
+	ExpiresAtNull sql.NullTime `json:"-"`
+	MaxClicks     int          `json:"max_clicks"`
+	FallbackURL   string       `json:"fallback_url"`
+
+	// Optional activation window and weekly schedule.
+	// Outside of them, visitors go to UnavailableURL or see a "not available" page.
+	StartsAt       *time.Time    `json:"starts_at"`
+	StartsAtNull   sql.NullTime  `json:"-"`
+	EndsAt         *time.Time    `json:"ends_at"`
+	EndsAtNull     sql.NullTime  `json:"-"`
+	Schedule       *LinkSchedule `json:"schedule"`
+	UnavailableURL string        `json:"unavailable_url"`
+
+	// How visitors are redirected: 301, 302, 307, 308 or "meta" for an interstitial page.
+	// Empty is the system default. Permanent ones are temporary while link is not static.
+	RedirectType string `json:"redirect_type"`
+
+	// Forward query string and path after keyword from request to destination,
+	// like "/promo/docs?utm_source=x" to "<url>/docs?utm_source=x".
+	ForwardQuery bool `json:"forward_query"`
+	ForwardPath  bool `json:"forward_path"`
+
+	// Campaign parameters appended to destination at redirect time.
+	UTM
+
+	// Destinations by device, OS, browser or country of visitor, first match wins.
+	// URL is the destination when none matches.
+	Targets []LinkTarget `json:"targets,omitempty"`
+
+	// Destinations in rotation, chosen by weight in each visit instead of URL.
+	// With StickyVariant, visitors keep the same variant by cookie.
+	Variants      []LinkVariant `json:"variants,omitempty"`
+	StickyVariant bool          `json:"sticky_variant"`
+
+	// VariantID is the variant chosen to a visit, it's never saved.
+	VariantID string `json:"-"`
+
+	// Password is the bcrypt hash, never sent to clients.
+	Password  string `json:"-"`
+	Protected bool   `json:"protected"`
+
 	UserID string     `json:"-"`
 	Clicks LinkClicks `json:"clicks"`
+	Tags   []Tag      `json:"tags,omitempty"`
+
+	// Folder of link, empty is none.
+	FolderID string `json:"folder_id"`
+}
+
+// CheckPassword compare a plain password with the link password hash.
+func (l *Link) CheckPassword(password string) (err error) {
+	return bcrypt.CompareHashAndPassword([]byte(l.Password), []byte(password))
+}
+
+// HashPassword store a bcrypt hash of password in link.
+func (l *Link) HashPassword(password string) error {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if err != nil {
+		return err
+	}
+
+	l.Password = string(bytes)
+	l.Protected = true
+	return nil
+}
+
+// LinkTarget represents a targeting rule of a link. Empty fields match any visitor.
+type LinkTarget struct {
+	Device  string `json:"device,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Browser string `json:"browser,omitempty"`
+	Country string `json:"country,omitempty"`
+	URL     string `json:"url"`
+}
+
+// LinkSchedule represents weekly hours of a link, like weekdays from 09:00 to 18:00 in a timezone.
+// Days are "mon" to "sun", and To must be after From in the same day ("24:00" is the end of day).
+type LinkSchedule struct {
+	Timezone string   `json:"timezone"`
+	Days     []string `json:"days"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+}
+
+// LinkVariant represents a destination in rotation, with a relative weight.
+type LinkVariant struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// LinkRevision represents a change in one field of a link, who did it and when.
+type LinkRevision struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	LinkID    string    `json:"link_id"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
 }
 
 // LinkClicks represents metrics from specific short URL.
 type LinkClicks struct {
-	Total int `json:"total"`
+	Total  int               `json:"total"`
+	Series []LinkClicksPoint `json:"series,omitempty"`
+
+	// Clicks in each variant of link, in the same period of series.
+	Variants []LinkVariantClicks `json:"variants,omitempty"`
+}
+
+// LinkVariantClicks represents clicks served by a variant.
+type LinkVariantClicks struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Total int    `json:"total"`
+}
+
+// LinkClicksPoint represents clicks in a period, like an hour or a day, starting at Timestamp.
+type LinkClicksPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Total     int       `json:"total"`
 }
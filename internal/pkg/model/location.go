@@ -20,8 +20,8 @@ type LocationIPv4 struct {
 // LocationIPv6 get info location from database by IPv4 or IPv6 address. // gorm:"index"
 type LocationIPv6 struct {
 	ID         string  `json:"id" gorm:"primaryKey;autoIncrement:false"`
-	RangeStart string  `json:"range_start" gorm:"index;type:BIGINT;unique"`
-	RangeEnd   string  `json:"range_end" gorm:"index;type:BIGINT;unique"`
+	RangeStart string  `json:"range_start" gorm:"index;type:NUMERIC(39,0);unique"`
+	RangeEnd   string  `json:"range_end" gorm:"index;type:NUMERIC(39,0);unique"`
 	Country    string  `json:"country"`
 	State      string  `json:"state"`
 	City       string  `json:"city"`
@@ -29,6 +29,15 @@ type LocationIPv6 struct {
 	Longitude  float64 `json:"longitude" gorm:"type:decimal(10,3);"`
 }
 
+// Location represents where an IP address is, found from LocationIPv4 or LocationIPv6 ranges.
+type Location struct {
+	Country   string  `json:"country"`
+	State     string  `json:"state"`
+	City      string  `json:"city"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 func (l *LocationIPv4) BeforeCreate(tx *gorm.DB) (err error) {
 	l.ID = uuid.New().String()
 	return
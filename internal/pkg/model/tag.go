@@ -1,14 +1,22 @@
 package model
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
+// Tag represents a label from user to organize links, like a campaign or a project.
 type Tag struct {
-	ID        string    `json:"id" gorm:"primaryKey;autoIncrement:false"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string       `json:"id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+	UpdatedAtNull sql.NullTime `json:"-"`
 
 	Name        string `json:"name"`
 	Description string `json:"description"`
 
-	Users []User `gorm:"many2many:user_tags;"`
+	// Total of links with this tag.
+	Links int `json:"links"`
+
+	UserID string `json:"-"`
 }
@@ -1,16 +1,20 @@
 package model
 
 import (
+	"database/sql"
 	"time"
 )
 
-// Token represent a JWT struct.
+// Token represent a JWT struct or a personal token.
 type Token struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	LastUse   time.Time `json:"last_use"`
+	ID          string       `json:"id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	LastUse     *time.Time   `json:"last_use"`
+	LastUseNull sql.NullTime `json:"-"`
 
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
 	Token     string    `json:"token"`
 	ExpiresIn time.Time `json:"expires_in"`
 
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+)
+
+// MaxUTMLength is the maximum of chars in each UTM parameter.
+const MaxUTMLength = 100
+
+// UTM represents campaign parameters appended to a link destination.
+type UTM struct {
+	Source   string `json:"utm_source"`
+	Medium   string `json:"utm_medium"`
+	Campaign string `json:"utm_campaign"`
+	Term     string `json:"utm_term"`
+	Content  string `json:"utm_content"`
+}
+
+// Valid check length of each parameter.
+func (u UTM) Valid() bool {
+	for _, v := range []string{u.Source, u.Medium, u.Campaign, u.Term, u.Content} {
+		if len(v) > MaxUTMLength {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge fill empty parameters with values from another UTM, like a preset.
+func (u UTM) Merge(other UTM) UTM {
+	fields := []struct {
+		dst *string
+		src string
+	}{
+		{&u.Source, other.Source},
+		{&u.Medium, other.Medium},
+		{&u.Campaign, other.Campaign},
+		{&u.Term, other.Term},
+		{&u.Content, other.Content},
+	}
+
+	for _, f := range fields {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+	return u
+}
+
+// Values are the non empty parameters, ready for a query string.
+func (u UTM) Values() url.Values {
+	values := url.Values{}
+	params := map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	}
+
+	for key, value := range params {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values
+}
+
+// UTMPreset represents reusable UTM parameters from a user or a group.
+type UTMPreset struct {
+	ID            string       `json:"id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+	UpdatedAtNull sql.NullTime `json:"-"`
+
+	Name string `json:"name"`
+	UTM
+
+	UserID      string         `json:"user_id,omitempty"`
+	UserIDNull  sql.NullString `json:"-"`
+	GroupID     string         `json:"group_id,omitempty"`
+	GroupIDNull sql.NullString `json:"-"`
+	CreatedBy   string         `json:"created_by"`
+}
@@ -0,0 +1,199 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// Error correction levels. Higher levels are bigger but can be read even when damaged.
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options of QR code image. Size is in pixels and Margin is in modules (QR code "pixels").
+type Options struct {
+	Format     string
+	Size       int
+	Level      string
+	Margin     int
+	Foreground string
+	Background string
+}
+
+// DefaultOptions is a black and white PNG with 256 pixels and the standard margin.
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       256,
+		Level:      "M",
+		Margin:     4,
+		Foreground: "000000",
+		Background: "ffffff",
+	}
+}
+
+// Validate options and normalize colors, so the same image has the same options.
+func (o *Options) Validate() (err error) {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return e.ErrQRInvalidFormat
+	}
+
+	if o.Size < MinSize || o.Size > MaxSize {
+		return e.ErrQRInvalidSize
+	}
+
+	o.Level = strings.ToUpper(o.Level)
+	if _, ok := levels[o.Level]; !ok {
+		return e.ErrQRInvalidLevel
+	}
+
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return e.ErrQRInvalidMargin
+	}
+
+	o.Foreground = strings.ToLower(strings.TrimPrefix(o.Foreground, "#"))
+	o.Background = strings.ToLower(strings.TrimPrefix(o.Background, "#"))
+
+	if _, err = parseColor(o.Foreground); err != nil {
+		return e.ErrQRInvalidColor
+	}
+
+	if _, err = parseColor(o.Background); err != nil {
+		return e.ErrQRInvalidColor
+	}
+	return nil
+}
+
+// String is a short and unique representation of options, to be used in cache keys.
+func (o Options) String() string {
+	return fmt.Sprintf("%s:%d:%s:%d:%s:%s", o.Format, o.Size, o.Level, o.Margin, o.Foreground, o.Background)
+}
+
+// ContentType of image for HTTP responses.
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode content in a QR code image. Options must be validated before.
+func Encode(content string, o Options) ([]byte, error) {
+	code, err := qrcode.New(content, levels[o.Level])
+	if err != nil {
+		return nil, err
+	}
+
+	// We draw our own margin.
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	fg, _ := parseColor(o.Foreground)
+	bg, _ := parseColor(o.Background)
+
+	if o.Format == FormatSVG {
+		return encodeSVG(bitmap, o, fg, bg), nil
+	}
+	return encodePNG(bitmap, o, fg, bg)
+}
+
+// encodePNG scale modules to image size. Modules can differ by one pixel when size is not a multiple.
+func encodePNG(bitmap [][]bool, o Options, fg, bg color.NRGBA) ([]byte, error) {
+	modules := len(bitmap) + 2*o.Margin
+
+	img := image.NewPaletted(image.Rect(0, 0, o.Size, o.Size), color.Palette{bg, fg})
+
+	for y := 0; y < o.Size; y++ {
+		my := y*modules/o.Size - o.Margin
+
+		for x := 0; x < o.Size; x++ {
+			mx := x*modules/o.Size - o.Margin
+
+			if my >= 0 && my < len(bitmap) && mx >= 0 && mx < len(bitmap) && bitmap[my][mx] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+
+	if err := encoder.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encodeSVG draw each run of dark modules in a row as one rectangle of the path.
+func encodeSVG(bitmap [][]bool, o Options, fg, bg color.NRGBA) []byte {
+	modules := len(bitmap) + 2*o.Margin
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		o.Size, o.Size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(bg))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hexColor(fg))
+
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start+o.Margin, y+o.Margin, x-start, x-start)
+		}
+	}
+
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
+
+// parseColor read colors like "ff8800" or "ff8800cc", with alpha.
+func parseColor(s string) (c color.NRGBA, err error) {
+	if len(s) != 6 && len(s) != 8 {
+		return c, fmt.Errorf("invalid color '%s'", s)
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return
+	}
+
+	c = color.NRGBA{R: b[0], G: b[1], B: b[2], A: 255}
+	if len(b) == 4 {
+		c.A = b[3]
+	}
+	return
+}
+
+func hexColor(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
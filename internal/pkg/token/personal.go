@@ -0,0 +1,186 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	e "github.com/wvoliveira/corgi/internal/pkg/errors"
+	"github.com/wvoliveira/corgi/internal/pkg/logger"
+)
+
+const (
+	// ScopeRead permit only read links. ScopeWrite permit manage links too.
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+
+	personalPrefix  = "corgi_"
+	personalLastUse = "token_personal_last_use:%s" // Ex.: token_personal_last_use:<token_id>
+
+	// Changes in user made directly in database, like role or active, reach its tokens after it.
+	personalCacheTTL = 5 * time.Minute
+)
+
+// Personal tokens can only be used in these API paths.
+var personalPaths = []string{"/api/links", "/api/clicks"}
+
+// PersonalToken is what we know about a personal token when it is used.
+// It stays in cache with key "token_personal:<hash>" for personalCacheTTL.
+type PersonalToken struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+	Scope  string `json:"scope"`
+}
+
+// NewPersonalToken create a random personal token and its hash.
+// Only the hash is saved, so the token must be showed to user just once.
+func NewPersonalToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+
+	token = personalPrefix + hex.EncodeToString(b)
+	return token, HashPersonalToken(token), nil
+}
+
+// HashPersonalToken get SHA-256 from personal token in hex format.
+func HashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CachePersonalToken save personal token info in cache, so we don't need database at each request.
+func CachePersonalToken(c context.Context, cache *redis.Client, hash string, pt PersonalToken) (err error) {
+	b, err := json.Marshal(pt)
+	if err != nil {
+		return
+	}
+
+	key := fmt.Sprintf(tokenPersonal, hash)
+	return cache.Set(c, key, b, personalCacheTTL).Err()
+}
+
+// DeletePersonalToken remove personal token from cache. It stops working right away.
+func DeletePersonalToken(c context.Context, cache *redis.Client, hash string) (err error) {
+	key := fmt.Sprintf(tokenPersonal, hash)
+	return cache.Del(c, key).Err()
+}
+
+// DeleteUserPersonalTokens remove all personal tokens of a user from cache,
+// so role and status of user are loaded again from database at next use.
+func DeleteUserPersonalTokens(c context.Context, db *sql.DB, cache *redis.Client, userID string) (err error) {
+	rows, err := db.QueryContext(c, "SELECT hash FROM tokens WHERE user_id = $1", userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		hash := ""
+		if err = rows.Scan(&hash); err != nil {
+			return
+		}
+		keys = append(keys, fmt.Sprintf(tokenPersonal, hash))
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+	return cache.Del(c, keys...).Err()
+}
+
+// ValidatePersonalToken get personal token info from cache or, if cache is cold, from database.
+// Last use is saved in database at most once per minute.
+func ValidatePersonalToken(c context.Context, db *sql.DB, cache *redis.Client, token string) (pt PersonalToken, err error) {
+	log := logger.Logger(c)
+
+	if !strings.HasPrefix(token, personalPrefix) {
+		return pt, e.ErrTokenInvalid
+	}
+
+	hash := HashPersonalToken(token)
+	key := fmt.Sprintf(tokenPersonal, hash)
+
+	val, err := cache.Get(c, key).Result()
+	if err == nil {
+		err = json.Unmarshal([]byte(val), &pt)
+	}
+
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Error().Caller().Msg(err.Error())
+		}
+
+		query := `SELECT t.id, t.user_id, u.role, t.scope FROM tokens t
+			INNER JOIN users u ON u.id = t.user_id
+			WHERE t.hash = $1 AND u.active = true`
+
+		err = db.QueryRowContext(c, query, hash).Scan(&pt.ID, &pt.UserID, &pt.Role, &pt.Scope)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return pt, e.ErrTokenInvalid
+			}
+			return
+		}
+
+		// Keep going on error from cache.
+		if err := CachePersonalToken(c, cache, hash, pt); err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+
+	ok, err := cache.SetNX(c, fmt.Sprintf(personalLastUse, pt.ID), time.Now().Unix(), time.Minute).Result()
+	if err != nil {
+		log.Error().Caller().Msg(err.Error())
+		return pt, nil
+	}
+
+	if ok {
+		_, err = db.ExecContext(c, "UPDATE tokens SET last_use = $1 WHERE id = $2", time.Now(), pt.ID)
+		if err != nil {
+			log.Error().Caller().Msg(err.Error())
+		}
+	}
+
+	return pt, nil
+}
+
+// ScopeAllows check if personal token scope permit a request.
+// Any scope can read links and clicks, but only "write" scope can change links.
+func ScopeAllows(scope, method, path string) bool {
+	allowed := false
+	for _, p := range personalPaths {
+		if path == p || strings.HasPrefix(path, p+"/") || strings.HasPrefix(path, p+"?") {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		return false
+	}
+
+	switch scope {
+	case ScopeWrite:
+		return true
+	case ScopeRead:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package token
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scope  string
+		method string
+		path   string
+		want   bool
+	}{
+		{"read lists links", ScopeRead, http.MethodGet, "/api/links", true},
+		{"read gets a link", ScopeRead, http.MethodGet, "/api/links/01HKQ1Z9", true},
+		{"read head", ScopeRead, http.MethodHead, "/api/links", true},
+		{"read clicks", ScopeRead, http.MethodGet, "/api/clicks", true},
+		{"read can't create", ScopeRead, http.MethodPost, "/api/links", false},
+		{"read can't update", ScopeRead, http.MethodPatch, "/api/links/01HKQ1Z9", false},
+		{"read can't delete", ScopeRead, http.MethodDelete, "/api/links/01HKQ1Z9", false},
+		{"write creates", ScopeWrite, http.MethodPost, "/api/links", true},
+		{"write deletes", ScopeWrite, http.MethodDelete, "/api/links/01HKQ1Z9", true},
+		{"write reads", ScopeWrite, http.MethodGet, "/api/clicks", true},
+		{"other paths", ScopeWrite, http.MethodGet, "/api/users/me", false},
+		{"tokens can't manage tokens", ScopeWrite, http.MethodPost, "/api/tokens", false},
+		{"prefix is not a path", ScopeWrite, http.MethodGet, "/api/linksx", false},
+		{"unknown scope", "admin", http.MethodGet, "/api/links", false},
+		{"empty scope", "", http.MethodGet, "/api/links", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopeAllows(tt.scope, tt.method, tt.path); got != tt.want {
+				t.Errorf("ScopeAllows(%q, %q, %q) = %v; want %v", tt.scope, tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
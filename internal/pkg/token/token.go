@@ -15,14 +15,24 @@ import (
 )
 
 const (
-	tokenAuth     = "token_auth:%s"
-	tokenPersonal = "token_personal:%s"
+	tokenAuth        = "token_auth:%s"
+	tokenPersonal    = "token_personal:%s"
+	tokenRevoked     = "token_revoked:%s"      // Ex.: token_revoked:<jti>
+	tokenRevokedUser = "token_revoked_user:%s" // Ex.: token_revoked_user:<user_id> = <unix timestamp>
+
+	accessExpiration  = 24 * time.Hour
+	refreshExpiration = 8640 * time.Hour
+
+	// TypeAccess and TypeRefresh are values of "type" claim in JWT.
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
 )
 
 var jwtKey = []byte(viper.GetString("SECRET_KEY"))
 
 type JWTClaim struct {
 	UserID string
+	Type   string `json:"type"`
 	model.User
 	jwt.StandardClaims
 }
@@ -51,11 +61,15 @@ func CreateToken(c context.Context, cache *redis.Client, typeToken string, userI
 }
 
 func GenerateJWTAccess(user model.User) (accessToken, refreshToken string, err error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+	now := time.Now()
+	expirationTime := now.Add(accessExpiration)
 
 	claims := &JWTClaim{
+		Type: TypeAccess,
 		User: user,
 		StandardClaims: jwt.StandardClaims{
+			Id:        ulid.Make().String(),
+			IssuedAt:  now.Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
@@ -66,10 +80,12 @@ func GenerateJWTAccess(user model.User) (accessToken, refreshToken string, err e
 		return
 	}
 
-	expirationTime = time.Now().Add(8640 * time.Hour)
+	expirationTime = now.Add(refreshExpiration)
 
+	claims.Type = TypeRefresh
 	claims.User = model.User{}
 	claims.UserID = user.ID
+	claims.StandardClaims.Id = ulid.Make().String()
 	claims.StandardClaims.ExpiresAt = expirationTime.Unix()
 
 	token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -79,11 +95,15 @@ func GenerateJWTAccess(user model.User) (accessToken, refreshToken string, err e
 }
 
 func GenerateJWTRefresh(user model.User) (tokenString string, err error) {
-	expirationTime := time.Now().Add(8640 * time.Hour)
+	now := time.Now()
+	expirationTime := now.Add(refreshExpiration)
 
 	claims := &JWTClaim{
 		UserID: user.ID,
+		Type:   TypeRefresh,
 		StandardClaims: jwt.StandardClaims{
+			Id:        ulid.Make().String(),
+			IssuedAt:  now.Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
@@ -93,11 +113,15 @@ func GenerateJWTRefresh(user model.User) (tokenString string, err error) {
 	return
 }
 
-func ValidateToken(signedToken string) (user model.User, err error) {
+// ParseToken check signature and expiration of any JWT from us and return its claims.
+func ParseToken(signedToken string) (claims *JWTClaim, err error) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&JWTClaim{},
 		func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, e.ErrParseToken
+			}
 			return []byte(jwtKey), nil
 		},
 	)
@@ -116,6 +140,93 @@ func ValidateToken(signedToken string) (user model.User, err error) {
 		return
 	}
 
+	return
+}
+
+// ValidateToken check an access token and return user from claims.
+// Refresh tokens are not accepted here, they are only for get a new access token.
+func ValidateToken(c context.Context, cache *redis.Client, signedToken string) (user model.User, err error) {
+	claims, err := ParseToken(signedToken)
+	if err != nil {
+		return
+	}
+
+	if claims.Type == TypeRefresh {
+		err = errors.New("refresh token can not be used as access token")
+		return
+	}
+
+	revoked, err := IsRevoked(c, cache, claims)
+	if err != nil {
+		return
+	}
+
+	if revoked {
+		err = errors.New("token was revoked")
+		return
+	}
+
 	user = claims.User
 	return
 }
+
+// OwnerID get user ID from access or refresh token claims.
+func (claims JWTClaim) OwnerID() string {
+	if claims.Type == TypeRefresh {
+		return claims.UserID
+	}
+	return claims.User.ID
+}
+
+// Revoke put token in revocation list until it expires by itself.
+func Revoke(c context.Context, cache *redis.Client, claims *JWTClaim) (err error) {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if claims.Id == "" || ttl <= 0 {
+		return
+	}
+
+	key := fmt.Sprintf(tokenRevoked, claims.Id)
+	return cache.Set(c, key, claims.OwnerID(), ttl).Err()
+}
+
+// RevokeUser revoke all tokens from a user issued until now, like a logout from all sessions.
+// Time is in milliseconds, so a new login right after it is not revoked too.
+// The mark lives while the longest token (refresh) can live.
+func RevokeUser(c context.Context, cache *redis.Client, userID string) (err error) {
+	key := fmt.Sprintf(tokenRevokedUser, userID)
+	return cache.Set(c, key, time.Now().UnixMilli(), refreshExpiration).Err()
+}
+
+// IsRevoked check if token was revoked by itself or by a logout from all sessions of its user.
+func IsRevoked(c context.Context, cache *redis.Client, claims *JWTClaim) (revoked bool, err error) {
+	if claims.Id != "" {
+		n, err := cache.Exists(c, fmt.Sprintf(tokenRevoked, claims.Id)).Result()
+		if err != nil {
+			return false, err
+		}
+
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	revokedAt, err := cache.Get(c, fmt.Sprintf(tokenRevokedUser, claims.OwnerID())).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return
+	}
+
+	return issuedAtMilli(claims) < revokedAt, nil
+}
+
+// issuedAtMilli get issue time in milliseconds from token ID, a ULID created with the token.
+// "iat" claim has only seconds.
+func issuedAtMilli(claims *JWTClaim) int64 {
+	id, err := ulid.Parse(claims.Id)
+	if err != nil {
+		return claims.IssuedAt * 1000
+	}
+	return int64(id.Time())
+}
@@ -0,0 +1,127 @@
+package useragent
+
+import "strings"
+
+// Device types.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// Operating systems.
+const (
+	OSiOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+)
+
+// Browsers.
+const (
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+	BrowserSafari  = "safari"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+)
+
+// Permitted values of each field, to validate targeting rules.
+var (
+	Devices  = []string{DeviceDesktop, DeviceMobile, DeviceTablet, DeviceBot}
+	Systems  = []string{OSiOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSChromeOS}
+	Browsers = []string{BrowserChrome, BrowserFirefox, BrowserSafari, BrowserEdge, BrowserOpera, BrowserSamsung}
+)
+
+// UserAgent is what we know about a client from its User-Agent header.
+// Unknown fields are empty.
+type UserAgent struct {
+	Device  string
+	OS      string
+	Browser string
+}
+
+// token is a piece of User-Agent and the value it means.
+type token struct {
+	text  string
+	value string
+}
+
+// Order matters: iPad has "Mac OS X", Android has "Linux", Edge and Opera have "Chrome",
+// and almost everyone has "Safari".
+var (
+	botTokens = []string{"bot", "crawler", "spider", "slurp", "facebookexternalhit", "preview", "curl/", "wget/"}
+
+	osTokens = []token{
+		{"iphone", OSiOS},
+		{"ipad", OSiOS},
+		{"ipod", OSiOS},
+		{"android", OSAndroid},
+		{"cros", OSChromeOS},
+		{"windows", OSWindows},
+		{"macintosh", OSMacOS},
+		{"mac os x", OSMacOS},
+		{"linux", OSLinux},
+	}
+
+	browserTokens = []token{
+		{"edg/", BrowserEdge},
+		{"edga/", BrowserEdge},
+		{"edgios/", BrowserEdge},
+		{"opr/", BrowserOpera},
+		{"opera", BrowserOpera},
+		{"samsungbrowser/", BrowserSamsung},
+		{"firefox/", BrowserFirefox},
+		{"fxios/", BrowserFirefox},
+		{"crios/", BrowserChrome},
+		{"chrome/", BrowserChrome},
+		{"safari/", BrowserSafari},
+	}
+)
+
+// Parse get device type, OS and browser from a User-Agent header.
+// Clients without User-Agent are unknown, not desktops.
+func Parse(ua string) (u UserAgent) {
+	s := strings.ToLower(ua)
+	if s == "" {
+		return
+	}
+
+	u.OS = find(s, osTokens)
+	u.Browser = find(s, browserTokens)
+
+	switch {
+	case contains(s, botTokens):
+		u.Device = DeviceBot
+	case strings.Contains(s, "ipad") || strings.Contains(s, "tablet") ||
+		(u.OS == OSAndroid && !strings.Contains(s, "mobile")):
+		u.Device = DeviceTablet
+	case strings.Contains(s, "mobi") || strings.Contains(s, "iphone") || strings.Contains(s, "ipod"):
+		u.Device = DeviceMobile
+	default:
+		u.Device = DeviceDesktop
+	}
+	return
+}
+
+func find(s string, tokens []token) string {
+	for _, t := range tokens {
+		if strings.Contains(s, t.text) {
+			return t.value
+		}
+	}
+	return ""
+}
+
+func contains(s string, texts []string) bool {
+	for _, text := range texts {
+		if strings.Contains(s, text) {
+			return true
+		}
+	}
+	return false
+}
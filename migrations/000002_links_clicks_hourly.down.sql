@@ -0,0 +1 @@
+DROP TABLE IF EXISTS links_clicks_hourly;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS links_clicks_hourly(
+	link_id VARCHAR (30) NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	bucket TIMESTAMP NOT NULL, -- hour of clicks, like counter:yyyy-mm-dd-hh in cache
+	total INT NOT NULL DEFAULT 0,
+	synced_at TIMESTAMP DEFAULT NOW(),
+
+	PRIMARY KEY (link_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_links_clicks_hourly_bucket ON links_clicks_hourly (bucket);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS clicks(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+
+	referer TEXT,
+	user_agent TEXT,
+	ip VARCHAR (50),
+	language VARCHAR (100),
+
+	link_id VARCHAR (30) NOT NULL,
+	CONSTRAINT fk_link_id FOREIGN KEY(link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_link_id_created_at ON clicks (link_id, created_at);
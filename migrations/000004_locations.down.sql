@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_clicks_country;
+
+ALTER TABLE clicks DROP COLUMN IF EXISTS city;
+ALTER TABLE clicks DROP COLUMN IF EXISTS state;
+ALTER TABLE clicks DROP COLUMN IF EXISTS country;
+
+DROP TABLE IF EXISTS locations_ipv6;
+
+DROP TABLE IF EXISTS locations_ipv4;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS locations_ipv4(
+	id VARCHAR (30) PRIMARY KEY,
+
+	range_start BIGINT NOT NULL, -- IPv4 as uint32, see location.IPv4ToLong
+	range_end BIGINT NOT NULL,
+	country VARCHAR (100),       -- ISO 3166-1 alpha-2 code, like BR
+	state VARCHAR (100),
+	city VARCHAR (100),
+	latitude DECIMAL (10,3),
+	longitude DECIMAL (10,3)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_ipv4_range_start ON locations_ipv4 (range_start);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_ipv4_range_end ON locations_ipv4 (range_end);
+
+CREATE TABLE IF NOT EXISTS locations_ipv6(
+	id VARCHAR (30) PRIMARY KEY,
+
+	range_start NUMERIC (39,0) NOT NULL, -- IPv6 as 128 bits integer, see location.IPv6ToLong
+	range_end NUMERIC (39,0) NOT NULL,
+	country VARCHAR (100),
+	state VARCHAR (100),
+	city VARCHAR (100),
+	latitude DECIMAL (10,3),
+	longitude DECIMAL (10,3)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_ipv6_range_start ON locations_ipv6 (range_start);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_ipv6_range_end ON locations_ipv6 (range_end);
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS country VARCHAR (100);
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS state VARCHAR (100);
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS city VARCHAR (100);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_country ON clicks (country);
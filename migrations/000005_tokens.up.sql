@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tokens(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP,
+	last_use TIMESTAMP,
+
+	name VARCHAR (100) NOT NULL,
+	scope VARCHAR (30) NOT NULL, -- read or write
+	hash VARCHAR (64) NOT NULL,  -- SHA-256 of personal token, the plain one is showed only at creation
+
+	user_id VARCHAR (30) NOT NULL,
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tokens_hash ON tokens (hash);
+CREATE INDEX IF NOT EXISTS idx_tokens_user_id ON tokens (user_id);
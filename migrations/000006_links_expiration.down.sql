@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN IF EXISTS fallback_url;
+ALTER TABLE links DROP COLUMN IF EXISTS max_clicks;
+ALTER TABLE links DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,3 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS max_clicks INT DEFAULT 0;        -- 0 is unlimited
+ALTER TABLE links ADD COLUMN IF NOT EXISTS fallback_url VARCHAR (300) DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS password VARCHAR (100) DEFAULT ''; -- bcrypt hash, empty is not protected
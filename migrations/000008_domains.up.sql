@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS domains(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP,
+	verified_at TIMESTAMP, -- null until ownership is proved
+
+	name VARCHAR (255) NOT NULL, -- hostname, with port if any. Ex.: go.example.com
+	token VARCHAR (64) NOT NULL, -- random value expected in DNS TXT record or HTTP well-known file
+
+	-- Domain belongs to a user or to a group.
+	user_id VARCHAR (30),
+	group_id VARCHAR (30),
+	created_by VARCHAR (30) NOT NULL,
+
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_group FOREIGN KEY(group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_name ON domains (name);
+CREATE INDEX IF NOT EXISTS idx_domains_user_id ON domains (user_id);
+CREATE INDEX IF NOT EXISTS idx_domains_group_id ON domains (group_id);
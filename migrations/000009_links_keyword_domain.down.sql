@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_links_domain_keyword;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_keyword ON links (keyword);
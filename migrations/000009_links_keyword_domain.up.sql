@@ -0,0 +1,3 @@
+-- Each domain has its own keywords, so the same keyword can exist in many domains.
+DROP INDEX IF EXISTS idx_links_keyword;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_links_domain_keyword ON links (domain, keyword);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS tags(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP,
+
+	name VARCHAR (50) NOT NULL,
+	description VARCHAR (300) DEFAULT '',
+
+	user_id VARCHAR (30) NOT NULL,
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_id_name ON tags (user_id, name);
+
+CREATE TABLE IF NOT EXISTS link_tags(
+	link_id VARCHAR (30) REFERENCES links(id) ON DELETE CASCADE,
+	tag_id VARCHAR (30) REFERENCES tags(id) ON DELETE CASCADE,
+	CONSTRAINT link_tags_pkey PRIMARY KEY (link_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_tags_tag_id ON link_tags (tag_id);
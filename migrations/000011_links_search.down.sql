@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_links_user_id_created_at;
+DROP INDEX IF EXISTS idx_links_search;
+ALTER TABLE links DROP COLUMN IF EXISTS search;
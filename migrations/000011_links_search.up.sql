@@ -0,0 +1,10 @@
+-- Words from title, keyword and URL, so links can be found by any part of them.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS search TSVECTOR GENERATED ALWAYS AS (
+	to_tsvector('simple',
+		COALESCE(title, '') || ' ' ||
+		COALESCE(keyword, '') || ' ' ||
+		regexp_replace(COALESCE(url, ''), '[/:.?=&#_-]+', ' ', 'g'))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_links_search ON links USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_links_user_id_created_at ON links (user_id, created_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS link_revisions(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+
+	link_id VARCHAR (30) NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	user_id VARCHAR (30) NOT NULL REFERENCES users(id),
+
+	field VARCHAR (30) NOT NULL, -- like url, keyword, domain, title or active
+	old_value VARCHAR (300) DEFAULT '',
+	new_value VARCHAR (300) DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_revisions_link_id ON link_revisions (link_id, created_at);
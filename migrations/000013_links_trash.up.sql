@@ -0,0 +1,6 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP; -- in trash since, NULL is not deleted
+
+-- Links deleted before trash existed are in trash too, with full retention from now before purged.
+UPDATE links SET deleted_at = NOW() WHERE active = false AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_links_deleted_at ON links (deleted_at) WHERE deleted_at IS NOT NULL;
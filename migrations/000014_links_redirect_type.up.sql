@@ -0,0 +1 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS redirect_type VARCHAR (10) DEFAULT ''; -- 301, 302, 307, 308 or meta, empty is the system default
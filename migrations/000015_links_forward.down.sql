@@ -0,0 +1,2 @@
+ALTER TABLE links DROP COLUMN IF EXISTS forward_path;
+ALTER TABLE links DROP COLUMN IF EXISTS forward_query;
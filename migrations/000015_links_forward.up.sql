@@ -0,0 +1,2 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS forward_query BOOLEAN DEFAULT false; -- merge query string from request into destination
+ALTER TABLE links ADD COLUMN IF NOT EXISTS forward_path BOOLEAN DEFAULT false;  -- append path after keyword to destination
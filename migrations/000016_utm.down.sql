@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS utm_presets;
+
+ALTER TABLE links DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE links DROP COLUMN IF EXISTS utm_term;
+ALTER TABLE links DROP COLUMN IF EXISTS utm_campaign;
+ALTER TABLE links DROP COLUMN IF EXISTS utm_medium;
+ALTER TABLE links DROP COLUMN IF EXISTS utm_source;
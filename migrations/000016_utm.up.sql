@@ -0,0 +1,30 @@
+-- UTM parameters appended to destination at redirect time.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_source VARCHAR (100) DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_medium VARCHAR (100) DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR (100) DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_term VARCHAR (100) DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_content VARCHAR (100) DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS utm_presets(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP,
+
+	name VARCHAR (50) NOT NULL,
+	utm_source VARCHAR (100) DEFAULT '',
+	utm_medium VARCHAR (100) DEFAULT '',
+	utm_campaign VARCHAR (100) DEFAULT '',
+	utm_term VARCHAR (100) DEFAULT '',
+	utm_content VARCHAR (100) DEFAULT '',
+
+	-- Preset belongs to a user or to a group.
+	user_id VARCHAR (30),
+	group_id VARCHAR (30),
+	created_by VARCHAR (30) NOT NULL,
+
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_group FOREIGN KEY(group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_utm_presets_user_id_name ON utm_presets (user_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_utm_presets_group_id_name ON utm_presets (group_id, name);
@@ -0,0 +1,4 @@
+ALTER TABLE link_revisions ALTER COLUMN new_value TYPE VARCHAR (300) USING LEFT(new_value, 300);
+ALTER TABLE link_revisions ALTER COLUMN old_value TYPE VARCHAR (300) USING LEFT(old_value, 300);
+
+DROP TABLE IF EXISTS link_targets;
@@ -0,0 +1,17 @@
+-- Targeting rules of a link, by User-Agent. First matching rule in position order wins.
+-- Empty device, os or browser match anything.
+CREATE TABLE IF NOT EXISTS link_targets(
+	link_id VARCHAR (30) NOT NULL,
+	position INTEGER NOT NULL,
+	device VARCHAR (20) DEFAULT '',
+	os VARCHAR (20) DEFAULT '',
+	browser VARCHAR (20) DEFAULT '',
+	url TEXT NOT NULL,
+
+	PRIMARY KEY (link_id, position),
+	CONSTRAINT fk_link FOREIGN KEY(link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Targets are saved in revisions as JSON, bigger than other fields.
+ALTER TABLE link_revisions ALTER COLUMN old_value TYPE TEXT;
+ALTER TABLE link_revisions ALTER COLUMN new_value TYPE TEXT;
@@ -0,0 +1 @@
+ALTER TABLE link_targets DROP COLUMN IF EXISTS country;
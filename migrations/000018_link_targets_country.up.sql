@@ -0,0 +1,2 @@
+-- Country of visitor in targeting rules, as ISO 3166-1 alpha-2 code like BR.
+ALTER TABLE link_targets ADD COLUMN IF NOT EXISTS country VARCHAR (2) DEFAULT '';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_clicks_variant_id_created_at;
+ALTER TABLE clicks DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE links DROP COLUMN IF EXISTS sticky_variant;
+
+DROP TABLE IF EXISTS link_variants;
@@ -0,0 +1,17 @@
+-- Destinations in rotation of a link, chosen by weight in each visit.
+CREATE TABLE IF NOT EXISTS link_variants(
+	id VARCHAR (30) PRIMARY KEY,
+	link_id VARCHAR (30) NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	weight INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_variants_link_id ON link_variants (link_id, position);
+
+-- Visitor keeps the same variant by cookie.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS sticky_variant BOOLEAN DEFAULT false;
+
+-- Variant served in each click, to compare them.
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS variant_id VARCHAR (30) REFERENCES link_variants(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_clicks_variant_id_created_at ON clicks (variant_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE links DROP COLUMN IF EXISTS unavailable_url;
+ALTER TABLE links DROP COLUMN IF EXISTS schedule;
+ALTER TABLE links DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE links DROP COLUMN IF EXISTS starts_at;
@@ -0,0 +1,8 @@
+-- Links work only between starts_at and ends_at and, with a schedule, in its weekly hours.
+-- Schedule is like {"timezone": "America/Sao_Paulo", "days": ["mon", "fri"], "from": "09:00", "to": "18:00"}.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS schedule JSONB;
+
+-- Destination outside of window. Empty shows a "not available" page.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS unavailable_url TEXT DEFAULT '';
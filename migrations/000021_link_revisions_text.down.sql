@@ -0,0 +1,2 @@
+-- Nothing to undo: columns were TEXT before this migration, 000017 down takes them back to VARCHAR.
+SELECT 1;
@@ -0,0 +1,4 @@
+-- Revision values are TEXT since 000017, as targets are saved there as JSON.
+-- Same change again, for databases migrated while it was in 000012 instead. No-op for the others.
+ALTER TABLE link_revisions ALTER COLUMN old_value TYPE TEXT;
+ALTER TABLE link_revisions ALTER COLUMN new_value TYPE TEXT;
@@ -0,0 +1,8 @@
+-- Names are unique again, so pending claims of a name verified or claimed before are removed.
+DELETE FROM domains d WHERE d.verified_at IS NULL AND EXISTS (
+	SELECT 1 FROM domains o WHERE o.name = d.name AND o.id <> d.id AND (o.verified_at IS NOT NULL OR o.id < d.id)
+);
+
+DROP INDEX IF EXISTS idx_domains_name;
+DROP INDEX IF EXISTS idx_domains_verified_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_name ON domains (name);
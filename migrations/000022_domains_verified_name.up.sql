@@ -0,0 +1,4 @@
+-- Only one owner of a verified domain. Pending claims don't block anyone, first to verify wins.
+DROP INDEX IF EXISTS idx_domains_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_name ON domains (name) WHERE verified_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_domains_name ON domains (name);
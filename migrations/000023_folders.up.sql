@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS folders(
+	id VARCHAR (30) PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP,
+
+	name VARCHAR (50) NOT NULL,
+
+	user_id VARCHAR (30) NOT NULL,
+	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_user_id_name ON folders (user_id, name);
+
+-- A link is in one folder at most. Links of a deleted folder are kept, without folder.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS folder_id VARCHAR (30) REFERENCES folders(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_links_folder_id ON links (folder_id) WHERE folder_id IS NOT NULL;